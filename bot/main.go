@@ -0,0 +1,110 @@
+// 示例机器人，支持 /echo、/time、/oncall 和 ping 命令
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/huoyijie/GoChat/lib/bot"
+	"github.com/huoyijie/GoChat/lib/client"
+)
+
+// 读取环境变量，没有设置时返回默认值
+func getenv(key, def string) string {
+	if v, found := os.LookupEnv(key); found {
+		return v
+	}
+	return def
+}
+
+// 服务器地址环境变量
+func svrAddr() string {
+	return getenv("SVR_ADDR", "127.0.0.1:8888")
+}
+
+// tls 配置环境变量，与终端客户端相同
+//
+// TLS: 为 1 时使用 tls 连接服务器，使用系统 CA 验证服务器证书
+//
+// TLS_CA: CA 证书文件路径
+//
+// TLS_FINGERPRINT: 服务器证书 sha256 指纹，设置后只信任该证书
+func tlsConfig() (*tls.Config, error) {
+	caFile := os.Getenv("TLS_CA")
+	fingerprint := os.Getenv("TLS_FINGERPRINT")
+	if os.Getenv("TLS") != "1" && len(caFile) == 0 && len(fingerprint) == 0 {
+		return nil, nil
+	}
+
+	host, _, err := net.SplitHostPort(svrAddr())
+	if err != nil {
+		return nil, err
+	}
+	return lib.ClientTLSConfig(host, caFile, fingerprint)
+}
+
+// 值班表，ONCALL 环境变量为逗号分隔的用户名，每周轮换一次
+func oncall(now time.Time) (string, error) {
+	var names []string
+	for _, name := range strings.Split(os.Getenv("ONCALL"), ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", errors.New("未配置值班表")
+	}
+
+	_, week := now.ISOWeek()
+	return names[week%len(names)], nil
+}
+
+func main() {
+	config, err := tlsConfig()
+	lib.FatalNotNil(err)
+
+	b := bot.New(
+		client.Options{Addr: svrAddr(), TLS: config},
+		getenv("BOT_USERNAME", "echo-bot"),
+		// 机器人密码，生产环境务必设置
+		getenv("BOT_PASSWORD", "echo-bot"),
+	)
+
+	b.Handle("/echo", "原样返回参数", func(ctx context.Context, req *bot.Request) error {
+		return req.Reply(ctx, req.Args)
+	})
+
+	b.Handle("/time", "服务器当前时间", func(ctx context.Context, req *bot.Request) error {
+		return req.Reply(ctx, time.Now().Format(time.RFC3339))
+	})
+
+	b.Handle("/oncall", "本周值班人员", func(ctx context.Context, req *bot.Request) error {
+		name, err := oncall(time.Now())
+		if err != nil {
+			return req.Reply(ctx, err.Error())
+		}
+		return req.Reply(ctx, fmt.Sprintf("本周值班: %s", name))
+	})
+
+	b.HandleRegex(regexp.MustCompile(`(?i)^ping$`), "回复 pong", func(ctx context.Context, req *bot.Request) error {
+		return req.Reply(ctx, "pong")
+	})
+
+	// ctrl+c 或者 kill 时退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := b.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		lib.FatalNotNil(err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 本地身份私钥在 KeyValue 表中的 key 前缀，后面加上用户名
+const IDENTITY_KEY = "identity_key:"
+
+// 旧版本保存的本地身份私钥，不区分用户
+const LEGACY_IDENTITY_KEY = "identity_key"
+
+var (
+	// 本地没有 username 的身份私钥
+	errNoIdentityKey = errors.New("identity key not exist")
+	// 服务器上已有其他设备发布的公钥，本地没有对应的私钥
+	errKeyConflict = errors.New("identity key published by another device, sign in with the backup passphrase")
+)
+
+// 读取 username 的本地 X25519 身份私钥，不存在时返回 errNoIdentityKey
+func loadIdentityKey(storage *storage_t, username string) (priv, pub *[32]byte, err error) {
+	kv, err := storage.GetValue(IDENTITY_KEY + username)
+	if err != nil {
+		err = errNoIdentityKey
+		return
+	}
+	b, err := base64.StdEncoding.DecodeString(kv.Value)
+	if err != nil {
+		return
+	}
+	if len(b) != 32 {
+		err = errors.New("invalid identity key")
+		return
+	}
+	priv = &[32]byte{}
+	copy(priv[:], b)
+	pub, err = lib.PublicKey(priv)
+	return
+}
+
+// 读取 username 的本地身份私钥，如果不存在则生成新的密钥对并保存。只在服务器上还没有公钥时调用
+func identityKey(storage *storage_t, username string) (priv, pub *[32]byte, err error) {
+	if priv, pub, err = loadIdentityKey(storage, username); err != errNoIdentityKey {
+		return
+	}
+	if priv, pub, err = lib.NewIdentityKey(); err != nil {
+		return
+	}
+	err = saveIdentityKey(storage, username, priv)
+	return
+}
+
+func saveIdentityKey(storage *storage_t, username string, priv *[32]byte) error {
+	return storage.NewKVS([]KeyValue{{Key: IDENTITY_KEY + username, Value: base64.StdEncoding.EncodeToString(priv[:])}})
+}
+
+// 旧版本的本地身份私钥与服务器上 username 的公钥 published 相同时，迁移为 username 的身份私钥
+func migrateIdentityKey(storage *storage_t, username string, published []byte) {
+	if _, err := storage.GetValue(IDENTITY_KEY + username); err == nil || len(published) == 0 {
+		return
+	}
+	kv, err := storage.GetValue(LEGACY_IDENTITY_KEY)
+	if err != nil {
+		return
+	}
+	b, err := base64.StdEncoding.DecodeString(kv.Value)
+	if err != nil || len(b) != 32 {
+		return
+	}
+	priv := (*[32]byte)(b)
+	if pub, err := lib.PublicKey(priv); err == nil && bytes.Equal(pub[:], published) {
+		lib.LogNotNil(saveIdentityKey(storage, username, priv))
+	}
+}
+
+// 使用密码登录或注册后同步身份密钥，同一用户的所有设备使用相同的身份密钥，都能解密发给自己和自己发送的加密消息
+//
+// passphrase 为备份口令，不会发送给服务器，为空时不导入也不上传私钥备份。服务器上还没有公钥时发布本地公钥；
+// 本地私钥与服务器上的公钥相同时补传备份；否则使用备份口令导入服务器上的备份。已发布的公钥不会被替换，
+// 无法导入时返回 errKeyConflict
+func syncIdentity(poster lib.Post, storage *storage_t, username, passphrase string) (err error) {
+	keyRes := &lib.KeyRes{}
+	if err = poster.Handle(&lib.GetKey{Username: username}, keyRes); err != nil {
+		return
+	}
+
+	if keyRes.Code < 0 {
+		priv, pub, err := identityKey(storage, username)
+		if err != nil {
+			return err
+		}
+		return publishKey(poster, pub, sealBackup(priv, username, passphrase))
+	}
+
+	migrateIdentityKey(storage, username, keyRes.Key)
+	if priv, pub, e := loadIdentityKey(storage, username); e == nil && bytes.Equal(pub[:], keyRes.Key) {
+		if len(keyRes.Backup) > 0 || len(passphrase) == 0 {
+			return
+		}
+		return publishKey(poster, pub, sealBackup(priv, username, passphrase))
+	}
+
+	if len(keyRes.Backup) == 0 || len(passphrase) == 0 {
+		return errKeyConflict
+	}
+	priv, err := lib.OpenBackup(keyRes.Backup, username, passphrase)
+	if err != nil {
+		return
+	}
+	if pub, err := lib.PublicKey(priv); err != nil || !bytes.Equal(pub[:], keyRes.Key) {
+		return errors.New("identity key backup does not match public key")
+	}
+	return saveIdentityKey(storage, username, priv)
+}
+
+// 使用备份口令加密身份私钥，口令为空或加密失败时不上传备份
+func sealBackup(priv *[32]byte, username, passphrase string) []byte {
+	if len(passphrase) == 0 {
+		return nil
+	}
+	backup, err := lib.SealBackup(priv, username, passphrase)
+	if err != nil {
+		return nil
+	}
+	return backup
+}
+
+// 使用 token 自动登录时没有密码，无法导入或备份身份密钥，只在服务器上还没有公钥时发布本地公钥
+func publishLocalKey(poster lib.Post, storage *storage_t, username string) (err error) {
+	keyRes := &lib.KeyRes{}
+	if err = poster.Handle(&lib.GetKey{Username: username}, keyRes); err != nil {
+		return
+	}
+	if keyRes.Code == 0 {
+		migrateIdentityKey(storage, username, keyRes.Key)
+		return
+	}
+
+	_, pub, err := identityKey(storage, username)
+	if err != nil {
+		return
+	}
+	return publishKey(poster, pub, nil)
+}
+
+// 发布身份公钥和私钥备份
+func publishKey(poster lib.Post, pub *[32]byte, backup []byte) (err error) {
+	keyRes := &lib.KeyRes{}
+	if err = poster.Handle(&lib.PublishKey{Key: pub[:], Backup: backup}, keyRes); err != nil {
+		return
+	}
+	if keyRes.Code < 0 {
+		err = fmt.Errorf("publish key error: %d", keyRes.Code)
+	}
+	return
+}
+
+// 查询对方的身份公钥，并与本地缓存的公钥比较。changed 为 true 表示对方公钥发生了变化
+// 服务器不可用时使用本地缓存的公钥
+func peerKey(poster lib.Post, storage *storage_t, username string) (key []byte, changed bool, err error) {
+	cacheKey := "pubkey:" + username
+	var cached []byte
+	if kv, e := storage.GetValue(cacheKey); e == nil {
+		cached, _ = base64.StdEncoding.DecodeString(kv.Value)
+	}
+
+	keyRes := &lib.KeyRes{}
+	if e := poster.Handle(&lib.GetKey{Username: username}, keyRes); e != nil || keyRes.Code < 0 {
+		if len(cached) == 0 {
+			err = errors.New("peer key not exist")
+			return
+		}
+		return cached, false, nil
+	}
+
+	key = keyRes.Key
+	changed = len(cached) > 0 && !bytes.Equal(cached, key)
+	err = storage.NewKVS([]KeyValue{{Key: cacheKey, Value: base64.StdEncoding.EncodeToString(key)}})
+	return
+}
+
+// 协商 self 与对方单聊使用的会话密钥
+func sessionKey(poster lib.Post, storage *storage_t, self, username string) (key *[32]byte, changed bool, err error) {
+	priv, _, err := loadIdentityKey(storage, self)
+	if err != nil {
+		return
+	}
+
+	peer, changed, err := peerKey(poster, storage, username)
+	if err != nil {
+		return
+	}
+
+	key, err = lib.SharedKey(priv, peer)
+	return
+}
@@ -1,190 +1,78 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"errors"
-	"fmt"
-	"math"
-	"math/rand"
 	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/huoyijie/GoChat/lib"
+	"github.com/huoyijie/GoChat/lib/client"
 )
 
-// 向服务器发送 packet。如果是同步请求，会通过 request.c 返回服务器响应数据，同时也会检查同步请求是否已超时。
-func sendTo(conn net.Conn, reqChan <-chan *request_t, resChan <-chan *response_t) (quit bool) {
-	// 从当前方法返回后，断开连接，清理资源等
-	defer conn.Close()
-
-	// 分配 packet.id
-	var id uint64
-	// 登记所有的同步请求，并等待响应
-	requests := make(map[uint64]*request_t)
-	// 同步请求超时检查间隔 50ms
-	timeoutTicker := time.NewTicker(50 * time.Millisecond)
-	defer timeoutTicker.Stop()
-	pingTicker := time.NewTicker(20 * time.Second)
-	defer pingTicker.Stop()
-
-	var sendPack = func(pack *lib.Packet) (err error) {
-		id++
-		pack.Id = id
-
-		bytes, err := lib.MarshalPack(pack)
-		if err != nil { // 序列化 packet 错误
-			return
-		}
-
-		_, err = conn.Write(bytes)
-		return
-	}
-
+// 处理服务器转发的消息、push 以及连接事件，写入本地存储
+func handleEvents(c *client.Client, storage *storage_t) {
 	for {
 		select {
+		case <-c.Done():
+			return
 
-		// 有服务器请求进来
-		case request, ok := <-reqChan:
-			if !ok { // renderUI 协程已退出，需要退出进程
-				quit = true
-				return
-			}
-
-			if err := sendPack(request.pack); err != nil { // 发送字节数据错误
-				return
-			}
-
-			if request.sync() { // 登记同步请求
-				// 如果 5s 内服务器没有返回，则超时
-				request.deadline = time.Now().Add(5 * time.Second)
-				requests[request.pack.Id] = request
-			}
-
-		// 通过 resChan 接收从 recvFrom 协程发送过来的响应对象
-		case response, ok := <-resChan:
-			if !ok { // recvFrom 协程已退出，连接已断开，需要重新连接和启动新协程
-				return
-			}
-
-			if request, found := requests[response.pack.Id]; found {
-				// 通过 request.c 返回服务器响应数据
-				request.c <- response
-				// 删除登记的同步请求
-				delete(requests, response.pack.Id)
-			}
-
-		// 每隔 50ms 检查是否有超时的同步请求
-		case <-timeoutTicker.C:
-			for id, request := range requests {
-				if time.Now().After(request.deadline) {
-					// 请求超时，返回空响应对象
-					request.c <- newResponse(nil)
-					// 删除登记的同步请求
-					delete(requests, id)
+		// 当前连接的登录用户收到新未读消息
+		case msg := <-c.Msgs():
+			// 新消息写入本地存储，也可能是自己从其他设备发送的消息
+			storage.NewMsg(&Message{
+				Id:    msg.Id,
+				Kind:  int32(msg.Kind),
+				From:  msg.From,
+				To:    msg.To,
+				Group: msg.Group,
+				Data:  msg.Data,
+			})
+
+		// 当前连接用户收到服务器 push
+		case push := <-c.Pushes():
+			// 消息回执直接更新本地消息状态
+			if push.Kind == lib.PushKind_MSG_RECEIPT {
+				receipt := &lib.Receipt{}
+				if err := lib.Unmarshal(push.Data, receipt); err != nil {
+					continue
+				}
+				// peer 为自己时，表示在其他设备上已读了这些消息
+				if kv, e := storage.GetValue("username"); e == nil && kv.Value == receipt.Peer {
+					storage.MarkRead(receipt.Ids)
+					continue
 				}
+				storage.UpdateStatus(receipt.Ids, int32(receipt.Status))
+				continue
 			}
-
-		// ping
-		case <-pingTicker.C:
-			bytes, err := lib.Marshal(&lib.Ping{Payload: []byte("天王盖地虎")})
-			if err != nil {
-				return
+			// 新 push 写入本地存储
+			storage.NewPush(&Push{Kind: int32(push.Kind), Data: push.Data})
+
+		// 重新连接后自动登录，保存刷新后的 token
+		case tokenRes := <-c.Tokens():
+			storage.StoreToken(tokenRes)
+
+		case err := <-c.Errs():
+			// 当前登录已被撤销，删除本地 token，下次启动时需要重新登录
+			var serverErr *client.ServerError
+			if errors.As(err, &serverErr) && serverErr.Code == lib.Err_Token_Revoked.Val() {
+				storage.DropPrivacy()
 			}
-
-			if err := sendPack(&lib.Packet{
-				Kind: lib.PackKind_PING,
-				Data: bytes,
-			}); err != nil {
-				return
+			// 协议版本不兼容，不会再重新连接
+			var versionErr *client.VersionError
+			if errors.As(err, &versionErr) {
+				lib.FatalNotNil(err)
 			}
 		}
 	}
 }
 
-// 从服务器接收 packet 的处理函数
-func handlePack(pack *lib.Packet, resChan chan<- *response_t, storage *storage_t) (err error) {
-	switch pack.Kind {
-
-	// pong
-	case lib.PackKind_PONG:
-		pong := &lib.Pong{}
-		if err := lib.Unmarshal(pack.Data, pong); err != nil {
-			return err
-		}
-		// log.Println(string(pong.Payload))
-
-	// 当前连接用户收到服务器 push
-	case lib.PackKind_PUSH:
-		push := &lib.Push{}
-		err = lib.Unmarshal(pack.Data, push)
-		if err != nil {
-			return
-		}
-		// 新 push 写入本地存储
-		storage.NewPush(&Push{Kind: int32(push.Kind), Data: push.Data})
-
-	// 当前连接的登录用户收到新未读消息
-	case lib.PackKind_MSG:
-		msg := &lib.Msg{}
-		err = lib.Unmarshal(pack.Data, msg)
-		if err != nil {
-			return
-		}
-		// 新消息写入本地存储
-		storage.NewMsg(&Message{
-			Id:   msg.Id,
-			Kind: int32(msg.Kind),
-			From: msg.From,
-			Data: msg.Data,
-		})
-
-	// 当前连接遇到系统异常，退出进程
-	case lib.PackKind_ERR:
-		errRes := &lib.ErrRes{}
-		err = lib.Unmarshal(pack.Data, errRes)
-		if err != nil {
-			return
-		}
-		err = fmt.Errorf("系统异常: %d", errRes.Code)
-
-	// 收到同步请求的响应
-	case lib.PackKind_RES:
-		// 当前是在 recvFrom 协程里，需要把 packet 封装为 response_t 对象，并通过 resChan channel 发到 sendTo 协程
-		resChan <- newResponse(pack)
-	}
-	return
-}
-
-// 从服务器接收 packet 并进行处理
-func recvFrom(conn net.Conn, resChan chan<- *response_t, storage *storage_t) {
-	// 协程退出前关闭 channel
-	defer close(resChan)
-
-	// 设置如何处理接收到的字节流，SplitFunc 会根据 packet 开头 length 把字节流分割为消息流
-	scanner := bufio.NewScanner(conn)
-	scanner.Split(lib.SplitFunc)
-
-	// 循环解析消息，每当解析出一条消息后，scan() 返回 true
-	for scanner.Scan() {
-		// 把 scanner 解析出的消息字节 slice 解析为 Pack
-		pack := &lib.Packet{}
-		if err := lib.Unmarshal(scanner.Bytes(), pack); err != nil {
-			return
-		}
-
-		// 执行 packet 处理逻辑
-		if err := handlePack(pack, resChan, storage); err != nil {
-			return
-		}
-	}
-}
-
 // 验证 token 是否有效
 func validateToken(poster lib.Post, storage *storage_t) (tokenRes *lib.TokenRes, err error) {
 	kv, err := storage.GetValue("token")
@@ -220,6 +108,8 @@ func renderHome(poster lib.Post, storage *storage_t) (renderHome bool) {
 		return true
 	}
 
+	// 发布端到端加密公钥，失败时仍可使用非加密聊天
+	publishLocalKey(poster, storage, tokenRes.Username)
 	return
 }
 
@@ -266,28 +156,27 @@ func svrAddr() string {
 	return svrAddr
 }
 
-// 连接服务器，连接失败按照指数回退策略重试，最多重试20次
-func connect(sigChan <-chan os.Signal) (net.Conn, error) {
-	for i := 0; i < 15; i++ {
-		select {
-		// 如果 UI 已退出，停止连接服务器
-		case <-sigChan:
-			return nil, nil
-		default:
-			// 客户端进行 tcp 拨号，请求连接服务器
-			if conn, err := net.DialTimeout("tcp", svrAddr(), 3*time.Second); err == nil {
-				return conn, nil
-			}
-
-			p := math.Pow(2, float64(i))
-			r := float64(rand.Intn(1000))
-			d := time.Duration(math.Min(p+r, 8000))
+// tls 配置环境变量
+//
+// TLS: 为 1 时使用 tls 连接服务器，使用系统 CA 验证服务器证书
+//
+// TLS_CA: CA 证书文件路径，开发模式下可直接使用服务器的自签名证书
+//
+// TLS_FINGERPRINT: 服务器证书 sha256 指纹，设置后只信任该证书
+//
+// 设置了 TLS_CA 或 TLS_FINGERPRINT 时自动启用 tls
+func tlsConfig() (*tls.Config, error) {
+	caFile := os.Getenv("TLS_CA")
+	fingerprint := os.Getenv("TLS_FINGERPRINT")
+	if os.Getenv("TLS") != "1" && len(caFile) == 0 && len(fingerprint) == 0 {
+		return nil, nil
+	}
 
-			// sleep
-			time.Sleep(d * time.Millisecond)
-		}
+	host, _, err := net.SplitHostPort(svrAddr())
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("connect error")
+	return lib.ClientTLSConfig(host, caFile, fingerprint)
 }
 
 func main() {
@@ -301,44 +190,17 @@ func main() {
 	storage, err := new(storage_t).Init(dbPath())
 	lib.FatalNotNil(err)
 
-	// 请求 channel
-	reqChan := make(chan *request_t, 1024)
-
-	// 渲染 UI
-	var poster lib.Post = newPoster(reqChan)
-	go renderUI(poster, storage, sigChan)
-
-	var reconnect bool
-
-	for {
-		// 连接服务器
-		conn, err := connect(sigChan)
-		lib.FatalNotNil(err)
-		if conn == nil { // quit UI
-			return
-		}
-
-		// 重新连接需要验证 token
-		if reconnect {
-			go func() {
-				if tokenRes, err := validateToken(poster, storage); err == nil {
-					storage.StoreToken(tokenRes)
-				}
-			}()
-			reconnect = false
-		}
-
-		// 响应 channel
-		resChan := make(chan *response_t, 1024)
-
-		// 启动单独的协程，接收处理或转发来自服务器的 packet
-		go recvFrom(conn, resChan, storage)
+	// 连接服务器，连接失败按照指数回退策略重试。之后断线时自动重新连接并登录
+	config, err := tlsConfig()
+	lib.FatalNotNil(err)
+	c := client.New(client.Options{Addr: svrAddr(), TLS: config})
+	lib.FatalNotNil(c.Connect(context.Background()))
+	defer c.Close()
 
-		// 当前协程调用并阻塞与 sendTo 函数，发送请求并接收响应
-		if quit := sendTo(conn, reqChan, resChan); quit {
-			return
-		}
+	// 启动单独的协程，处理来自服务器的消息和 push
+	go handleEvents(c, storage)
 
-		reconnect = true
-	}
+	// 渲染 UI，UI 退出或者收到信号时退出进程
+	go renderUI(c.Post(), storage, sigChan)
+	<-sigChan
 }
@@ -32,11 +32,15 @@ type KeyValue struct {
 
 // 本地聊天记录
 type Message struct {
-	Id   int64 `gorm:"primaryKey;autoIncrement:false"`
-	Kind int32
-	From string
-	Data []byte
-	Read bool
+	Id    int64 `gorm:"primaryKey;autoIncrement:false"`
+	Kind  int32
+	From  string
+	To    string
+	Group uint64 `gorm:"index"`
+	Data  []byte
+	Read  bool
+	// 自己发送的消息状态: lib.MsgStatus
+	Status int32
 }
 
 // 服务器 push
@@ -108,22 +112,111 @@ func (s *storage_t) NewMsg(msg *Message) (err error) {
 	return
 }
 
-// 获取某个用户发给自己的未读消息列表
-func (s *storage_t) GetMsgList(from string) (msgList []Message, err error) {
+// 批量写入从服务器获取的历史消息，本地已存在的消息只更新状态
+func (s *storage_t) NewMsgList(msgList []Message) (err error) {
+	if len(msgList) == 0 {
+		return
+	}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status"}),
+	}).Create(&msgList).Error
+	return
+}
+
+// 收到消息回执，更新自己发送的消息状态。状态只会前进，不会回退
+func (s *storage_t) UpdateStatus(ids []int64, status int32) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	err = s.db.Model(&Message{}).Where("id IN ? AND status < ?", ids, status).Update("status", status).Error
+	return
+}
+
+// 标记消息为已读
+func (s *storage_t) MarkRead(ids []int64) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	err = s.db.Model(&Message{}).Where("id IN ?", ids).Update("read", true).Error
+	return
+}
+
+// 查询消息状态
+func (s *storage_t) GetStatus(ids []int64) (status map[int64]int32, err error) {
+	var msgList []Message
+	if err = s.db.Select("id", "status").Where("id IN ?", ids).Find(&msgList).Error; err != nil {
+		return
+	}
+
+	status = make(map[int64]int32, len(msgList))
+	for i := range msgList {
+		status[msgList[i].Id] = msgList[i].Status
+	}
+	return
+}
+
+// 分页查询本地历史消息，返回 id 小于 cursor 的最近 limit 条消息（按 id 升序），并把这些消息标记为已读
+func (s *storage_t) GetHistory(peer string, group uint64, cursor int64, limit int) (msgList []Message, err error) {
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		msg := &Message{From: from}
-		res := tx.Model(msg).Where(msg).Update("read", true)
-		if err := res.Error; err != nil {
+		q := tx.Model(&Message{})
+		if group > 0 {
+			q = q.Where("`group` = ?", group)
+		} else {
+			q = q.Where("`group` = 0 AND (`from` = ? OR `to` = ?)", peer, peer)
+		}
+		if cursor > 0 {
+			q = q.Where("id < ?", cursor)
+		}
+
+		if err := q.Order("id DESC").Limit(limit).Find(&msgList).Error; err != nil {
 			return err
 		}
 
-		if unReadMsgCnt := res.RowsAffected; unReadMsgCnt > 0 {
-			msg.Read = true
-			if err := tx.Where(msg).Order("id").Find(&msgList).Error; err != nil {
+		ids := make([]int64, len(msgList))
+		for i := range msgList {
+			ids[i] = msgList[i].Id
+		}
+		if len(ids) > 0 {
+			if err := tx.Model(&Message{}).Where("id IN ?", ids).Update("read", true).Error; err != nil {
 				return err
 			}
+		}
+		return nil
+	})
+
+	// 按 id 升序返回
+	for i, j := 0, len(msgList)-1; i < j; i, j = i+1, j-1 {
+		msgList[i], msgList[j] = msgList[j], msgList[i]
+	}
+	return
+}
+
+// 获取与某个用户单聊的未读消息列表，包括对方发给自己的消息，以及自己从其他设备发给对方的消息
+func (s *storage_t) GetMsgList(peer string) (msgList []Message, err error) {
+	msgList, err = s.readMsgList("(`from` = ? OR `to` = ?) AND `group` = 0", peer, peer)
+	return
+}
+
+// 获取某个群组的未读消息列表
+func (s *storage_t) GetGroupMsgList(group uint64) (msgList []Message, err error) {
+	msgList, err = s.readMsgList("`group` = ?", group)
+	return
+}
+
+// 读取满足条件的未读消息，并标记为已读。消息会保留在本地作为聊天记录
+func (s *storage_t) readMsgList(query string, args ...any) (msgList []Message, err error) {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(query, args...).Where("read = ?", false).Order("id").Find(&msgList).Error; err != nil {
+			return err
+		}
 
-			if err := tx.Where(msg).Delete(msg).Error; err != nil {
+		if len(msgList) > 0 {
+			ids := make([]int64, len(msgList))
+			for i := range msgList {
+				ids[i] = msgList[i].Id
+			}
+			if err := tx.Model(&Message{}).Where("id IN ?", ids).Update("read", true).Error; err != nil {
 				msgList = nil
 				return err
 			}
@@ -134,9 +227,35 @@ func (s *storage_t) GetMsgList(from string) (msgList []Message, err error) {
 	return
 }
 
+// 获取当前登录用户每个群组的未读消息数量
+func (s *storage_t) UnReadGroupMsgCount() (msgCount map[uint64]uint32, err error) {
+	// 不统计自己从其他设备发送的群消息
+	self := s.db.Model(&KeyValue{}).Select("value").Where("key = ?", "username")
+	rows, err := s.db.Model(&Message{}).Select("group", "COUNT(*) as count").Where("`group` > 0 AND read = ? AND `from` <> (?)", false, self).Group("group").Rows()
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+	msgCount = make(map[uint64]uint32)
+	for rows.Next() {
+		var (
+			group uint64
+			count uint32
+		)
+		if err = rows.Scan(&group, &count); err != nil {
+			continue
+		}
+		msgCount[group] = count
+	}
+	return
+}
+
 // 获取当前登录用户的未读消息数量
 func (s *storage_t) UnReadMsgCount() (msgCount map[string]uint32, err error) {
-	rows, err := s.db.Model(&Message{}).Select("from", "COUNT(*) as count").Group("from").Rows()
+	// 不统计自己从其他设备发送的消息
+	self := s.db.Model(&KeyValue{}).Select("value").Where("key = ?", "username")
+	rows, err := s.db.Model(&Message{}).Select("from", "COUNT(*) as count").Where("`group` = 0 AND read = ? AND `from` <> (?)", false, self).Group("from").Rows()
 	if err != nil {
 		return
 	}
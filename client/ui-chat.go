@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,18 +13,50 @@ import (
 	"github.com/muesli/reflow/indent"
 )
 
+// 每次加载历史消息条数
+const HISTORY_PAGE_SIZE = 20
+
+// 消息状态标记
+var statusMarks = []string{
+	lib.MsgStatus_SENT:      subtle(" ✓"),
+	lib.MsgStatus_DELIVERED: subtle(" ✓✓"),
+	lib.MsgStatus_READ:      colorFg(" ✓✓", "42"),
+}
+
+// 返回消息状态标记，未知状态不显示
+func statusMark(status int32) string {
+	if status < 0 || int(status) >= len(statusMarks) {
+		return ""
+	}
+	return statusMarks[status]
+}
+
+// 聊天窗口中的一行，msg 为 nil 时显示提示信息 note
+type chat_line_t struct {
+	msg  *Message
+	note string
+}
+
 type ui_chat_t struct {
 	ui_base_t
 	from        string
 	to          string
+	group       uint64
 	viewport    viewport.Model
-	messages    []string
+	messages    []chat_line_t
 	textarea    textarea.Model
 	senderStyle lipgloss.Style
 	err         error
+	// 已加载的最早一条消息 id，向上滚动时加载更早的历史消息
+	oldest int64
+	noMore bool
+	// 单聊是否开启端到端加密，key 为与对方协商的会话密钥
+	sealed bool
+	key    *[32]byte
 }
 
-func initialChat(to string, base ui_base_t) ui_chat_t {
+// to 为聊天对象用户名或群组名称，group 不为 0 时表示群聊
+func initialChat(to string, group uint64, base ui_base_t) ui_chat_t {
 	kv, err := base.storage.GetValue("username")
 	lib.FatalNotNil(err)
 
@@ -46,16 +79,203 @@ func initialChat(to string, base ui_base_t) ui_chat_t {
 
 	ta.KeyMap.InsertNewline.SetEnabled(false)
 
-	return ui_chat_t{
+	m := ui_chat_t{
 		ui_base_t:   base,
 		from:        kv.Value,
 		to:          to,
+		group:       group,
 		textarea:    ta,
-		messages:    []string{},
+		messages:    []chat_line_t{},
 		viewport:    vp,
 		senderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
 		err:         nil,
 	}
+	if group == 0 {
+		if kv, err := base.storage.GetValue("e2e:" + to); err == nil && kv.Value == "1" {
+			m.sealed = true
+		}
+		m.initKey()
+	}
+	m.loadHistory()
+	m.viewport.GotoBottom()
+	return m
+}
+
+// 渲染一行消息，单聊时自己发送的消息后面显示状态标记
+func (m *ui_chat_t) render(line chat_line_t) string {
+	if line.msg == nil {
+		return subtle(line.note)
+	}
+
+	s := m.senderStyle.Render(fmt.Sprintf("%s: ", line.msg.From)) + m.plaintext(line.msg)
+	if m.group == 0 && line.msg.From == m.from {
+		s += statusMark(line.msg.Status)
+	}
+	return s
+}
+
+// 返回消息明文，加密消息使用会话密钥解密
+func (m *ui_chat_t) plaintext(msg *Message) string {
+	if msg.Kind != int32(lib.MsgKind_SEALED) {
+		return string(msg.Data)
+	}
+	if m.key != nil {
+		if data, err := lib.Open(msg.Data, m.key, lib.MsgAD(msg.From, msg.To)); err == nil {
+			return "🔒 " + string(data)
+		}
+	}
+	return subtle("🔒 [无法解密]")
+}
+
+// 协商单聊会话密钥，对方公钥变化时给出提示
+func (m *ui_chat_t) initKey() error {
+	key, changed, err := sessionKey(m.poster, m.storage, m.from, m.to)
+	if err != nil {
+		return err
+	}
+	m.key = key
+	if changed {
+		m.note(fmt.Sprintf("%s 的公钥已变更，之前的加密消息可能无法解密", m.to))
+	}
+	return nil
+}
+
+// 开启或关闭端到端加密
+func (m *ui_chat_t) toggleSealed() {
+	if !m.sealed && m.key == nil {
+		if err := m.initKey(); m.key == nil {
+			if errors.Is(err, errNoIdentityKey) {
+				m.note("本机没有身份私钥，使用备份口令重新登录后才能开启端到端加密")
+			} else {
+				m.note(fmt.Sprintf("%s 尚未发布公钥，无法开启端到端加密", m.to))
+			}
+			return
+		}
+	}
+
+	m.sealed = !m.sealed
+	value := "0"
+	if m.sealed {
+		value = "1"
+	}
+	m.storage.NewKVS([]KeyValue{{Key: "e2e:" + m.to, Value: value}})
+
+	if m.sealed {
+		m.note("已开启端到端加密")
+	} else {
+		m.note("已关闭端到端加密")
+	}
+}
+
+// 重新渲染所有消息
+func (m *ui_chat_t) refresh() {
+	lines := make([]string, len(m.messages))
+	for i := range m.messages {
+		lines[i] = m.render(m.messages[i])
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// 追加提示信息
+func (m *ui_chat_t) note(note string) {
+	m.messages = append(m.messages, chat_line_t{note: note})
+	m.refresh()
+	m.viewport.GotoBottom()
+}
+
+// 向服务器发送已读回执
+func (m *ui_chat_t) sendReadReceipt(msgList []Message) {
+	var ids []int64
+	for i := range msgList {
+		if msgList[i].From != m.from {
+			ids = append(ids, msgList[i].Id)
+		}
+	}
+	if len(ids) > 0 {
+		m.poster.Send(&lib.Receipt{Status: lib.MsgStatus_READ, Ids: ids})
+	}
+}
+
+// 更新自己发送的单聊消息状态
+func (m *ui_chat_t) updateStatus() {
+	if m.group > 0 {
+		return
+	}
+
+	var ids []int64
+	for _, line := range m.messages {
+		if line.msg != nil && line.msg.From == m.from && line.msg.Status < int32(lib.MsgStatus_READ) {
+			ids = append(ids, line.msg.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	status, err := m.storage.GetStatus(ids)
+	if err != nil {
+		return
+	}
+	for _, line := range m.messages {
+		if line.msg != nil {
+			if s, found := status[line.msg.Id]; found {
+				line.msg.Status = s
+			}
+		}
+	}
+}
+
+// 加载比 m.oldest 更早的一页历史消息。先从服务器同步到本地存储，如果服务器不可用则只读取本地存储
+func (m *ui_chat_t) loadHistory() {
+	if m.noMore {
+		return
+	}
+
+	history := &lib.History{Group: m.group, Cursor: m.oldest, Limit: HISTORY_PAGE_SIZE}
+	if m.group == 0 {
+		history.Peer = m.to
+	}
+	historyRes := &lib.HistoryRes{}
+	if err := m.poster.Handle(history, historyRes); err == nil && historyRes.Code == 0 {
+		msgList := make([]Message, len(historyRes.Msgs))
+		var unread []Message
+		for i, msg := range historyRes.Msgs {
+			msgList[i] = Message{
+				Id:     msg.Id,
+				Kind:   int32(msg.Kind),
+				From:   msg.From,
+				To:     msg.To,
+				Group:  msg.Group,
+				Data:   msg.Data,
+				Read:   true,
+				Status: int32(msg.Status),
+			}
+			if msg.Status != lib.MsgStatus_READ {
+				unread = append(unread, msgList[i])
+			}
+		}
+		m.storage.NewMsgList(msgList)
+		m.sendReadReceipt(unread)
+	}
+
+	msgList, err := m.storage.GetHistory(m.to, m.group, m.oldest, HISTORY_PAGE_SIZE)
+	if err != nil {
+		return
+	}
+	if len(msgList) < HISTORY_PAGE_SIZE {
+		m.noMore = true
+	}
+	if len(msgList) == 0 {
+		return
+	}
+
+	lines := make([]chat_line_t, 0, len(msgList)+len(m.messages))
+	for i := range msgList {
+		lines = append(lines, chat_line_t{msg: &msgList[i]})
+	}
+	m.messages = append(lines, m.messages...)
+	m.oldest = msgList[0].Id
+	m.refresh()
 }
 
 func (m ui_chat_t) Init() tea.Cmd {
@@ -79,28 +299,88 @@ func (m ui_chat_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlR:
 			users := initialUsers(m.ui_base_t)
 			return users, users.Init()
+		case tea.KeyCtrlE:
+			if m.group == 0 {
+				m.toggleSealed()
+			}
+		case tea.KeyUp, tea.KeyPgUp:
+			// 滚动到顶部后，继续向上滚动加载更早的历史消息
+			if m.viewport.AtTop() {
+				m.loadHistory()
+				m.viewport.GotoTop()
+			}
 		case tea.KeyEnter:
 			if len(strings.TrimSpace(m.textarea.Value())) == 0 {
 				return m, nil
 			}
 
-			if err := m.poster.Send(&lib.Msg{Kind: lib.MsgKind_TEXT, From: m.from, To: m.to, Data: []byte(m.textarea.Value())}); err != nil {
-				return m, tea.Quit
+			// 群聊命令
+			if m.group > 0 && strings.HasPrefix(m.textarea.Value(), "/") {
+				return m.groupCommand(m.textarea.Value())
+			}
+
+			msg := &lib.Msg{Kind: lib.MsgKind_TEXT, From: m.from, Data: []byte(m.textarea.Value())}
+			if m.group > 0 {
+				msg.Group = m.group
+			} else {
+				msg.To = m.to
+			}
+			// 开启端到端加密后，服务器只转发密文
+			if m.sealed {
+				if m.key == nil {
+					m.note("无法获取对方公钥，消息未发送")
+					return m, nil
+				}
+				data, err := lib.Seal(msg.Data, m.key, lib.MsgAD(msg.From, msg.To))
+				if err != nil {
+					m.note(fmt.Sprintf("加密消息异常: %v", err))
+					return m, nil
+				}
+				msg.Kind, msg.Data = lib.MsgKind_SEALED, data
+			}
+			msgRes := &lib.MsgRes{}
+			if err := m.poster.Handle(msg, msgRes); err != nil {
+				m.note(fmt.Sprintf("发送消息异常: %v", err))
+				return m, nil
+			} else if msgRes.Code < 0 {
+				m.note(fmt.Sprintf("发送消息异常: %d", msgRes.Code))
+				return m, nil
+			}
+
+			// 服务器返回消息 ID 后，存储自己发送的消息
+			sent := &Message{
+				Id:    msgRes.Id,
+				Kind:  int32(msg.Kind),
+				From:  m.from,
+				To:    msg.To,
+				Group: msg.Group,
+				Data:  msg.Data,
+				Read:  true,
 			}
+			m.storage.NewMsgList([]Message{*sent})
 
-			m.messages = append(m.messages, m.senderStyle.Render(fmt.Sprintf("%s: ", m.from))+m.textarea.Value())
-			m.viewport.SetContent(strings.Join(m.messages, "\n"))
+			m.messages = append(m.messages, chat_line_t{msg: sent})
+			m.refresh()
 			m.viewport.GotoBottom()
 			m.textarea.Reset()
 		}
 
 	case tick_msg_t:
-		msgList, _ := m.storage.GetMsgList(m.to)
+		var msgList []Message
+		if m.group > 0 {
+			msgList, _ = m.storage.GetGroupMsgList(m.group)
+		} else {
+			msgList, _ = m.storage.GetMsgList(m.to)
+		}
 		for i := range msgList {
-			m.messages = append(m.messages, m.senderStyle.Render(fmt.Sprintf("%s: ", msgList[i].From))+string(msgList[i].Data))
+			m.messages = append(m.messages, chat_line_t{msg: &msgList[i]})
+		}
+		m.sendReadReceipt(msgList)
+		m.updateStatus()
+		m.refresh()
+		if len(msgList) > 0 {
+			m.viewport.GotoBottom()
 		}
-		m.viewport.SetContent(strings.Join(m.messages, "\n"))
-		m.viewport.GotoBottom()
 		return m, tick()
 
 	// We handle errors just like any other message
@@ -112,12 +392,57 @@ func (m ui_chat_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(tiCmd, vpCmd)
 }
 
+// 执行群聊命令: /invite user..., /kick user..., /leave
+func (m ui_chat_t) groupCommand(cmd string) (tea.Model, tea.Cmd) {
+	m.textarea.Reset()
+
+	fields := strings.Fields(cmd)
+	groupRes := &lib.GroupRes{}
+	var err error
+	switch fields[0] {
+	case "/invite":
+		err = m.poster.Handle(&lib.GroupInvite{Id: m.group, Members: fields[1:]}, groupRes)
+	case "/kick":
+		err = m.poster.Handle(&lib.GroupKick{Id: m.group, Members: fields[1:]}, groupRes)
+	case "/leave":
+		err = m.poster.Handle(&lib.GroupLeave{Id: m.group}, groupRes)
+	default:
+		m.note("可用命令: /invite user..., /kick user..., /leave")
+		return m, nil
+	}
+
+	switch {
+	case err != nil:
+		m.note(fmt.Sprintf("%s 失败: %v", fields[0], err))
+	case groupRes.Code < 0:
+		m.note(fmt.Sprintf("%s 失败: %d", fields[0], groupRes.Code))
+	case fields[0] == "/leave":
+		users := initialUsers(m.ui_base_t)
+		return users, users.Init()
+	default:
+		m.note("群成员: " + strings.Join(groupRes.Group.Members, ", "))
+	}
+	return m, nil
+}
+
 func (m ui_chat_t) View() string {
-	help := subtle("enter send") + dot + subtle("ctrl+r back") + dot + subtle("esc quit")
+	help := subtle("enter send") + dot + subtle("↑/pgup history") + dot + subtle("ctrl+r back") + dot + subtle("esc quit")
+
+	title := "@" + m.to
+	if m.group == 0 {
+		help = checkbox("ctrl+e e2e", m.sealed) + dot + help
+		if m.sealed {
+			title += " 🔒"
+		}
+	}
+	if m.group > 0 {
+		title = "#" + m.to
+		help = subtle("/invite /kick /leave") + dot + help
+	}
 
 	s := fmt.Sprintf(
 		"%s\n\n%s\n\n%s\n\n%s",
-		inputStyle.Width(32).Render("@"+m.to),
+		inputStyle.Width(32).Render(title),
 		m.viewport.View(),
 		m.textarea.View(),
 		help,
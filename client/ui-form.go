@@ -39,6 +39,16 @@ func passwordValidator(s string) (err error) {
 	return
 }
 
+// 备份口令实时输入验证器，只允许输入可见 ASCII 字符，可以为空
+func passphraseValidator(s string) (err error) {
+	for _, r := range s {
+		if r < '!' || r > '~' {
+			return errors.New("passphrase is invalid")
+		}
+	}
+	return
+}
+
 type check_fn = func(string) (bool, string)
 
 // 表单提交后检查用户名长度
@@ -61,6 +71,37 @@ func passwordLenCheck(s string) (ok bool, hint string) {
 	return
 }
 
+// 表单提交后检查备份口令长度，不填时不备份身份密钥
+func passphraseLenCheck(s string) (ok bool, hint string) {
+	if len(s) > 0 && len(s) < 12 {
+		hint = "备份口令至少包含12个字符，不填时不备份端到端加密密钥"
+		return
+	}
+	ok = true
+	return
+}
+
+// 备份口令不能与登录密码相同，服务器可以通过密码摘要猜出登录密码
+func passphraseCheck(m *ui_form_t, password, passphrase int) bool {
+	if len(m.inputs[passphrase].Value()) > 0 && m.inputs[passphrase].Value() == m.inputs[password].Value() {
+		m.errs[passphrase] = "备份口令不能与密码相同"
+		return false
+	}
+	return true
+}
+
+// 备份口令输入框
+func passphraseInput() textinput.Model {
+	t := textinput.New()
+	t.CursorStyle = cursorStyle
+	t.CharLimit = 64
+	t.Placeholder = "可选，不会发送给服务器"
+	t.EchoMode = textinput.EchoPassword
+	t.EchoCharacter = '•'
+	t.Validate = passphraseValidator
+	return t
+}
+
 // 表单提交处理函数
 type submit_fn = func(*ui_form_t) (tea.Model, tea.Cmd)
 
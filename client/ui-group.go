@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 群成员实时输入验证，只允许输入小写字母、数字、空格和逗号
+func membersValidator(s string) (err error) {
+	membersRegexp := "^[a-z\\d, ]*$"
+	re, err := regexp.Compile(membersRegexp)
+	if err != nil {
+		return
+	}
+
+	if !re.MatchString(s) {
+		err = errors.New("members is invalid")
+	}
+	return
+}
+
+// 表单提交后检查群组名称长度
+func groupNameLenCheck(s string) (ok bool, hint string) {
+	if len(strings.TrimSpace(s)) == 0 {
+		hint = "群组名称不能为空"
+		return
+	}
+	ok = true
+	return
+}
+
+// 群成员可以为空
+func membersLenCheck(s string) (ok bool, hint string) {
+	ok = true
+	return
+}
+
+func groupSubmit(m *ui_form_t) (tea.Model, tea.Cmd) {
+	members := strings.FieldsFunc(m.inputs[1].Value(), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	groupRes := &lib.GroupRes{}
+	if err := m.poster.Handle(&lib.GroupCreate{
+		Name:    m.inputs[0].Value(),
+		Members: members,
+	}, groupRes); err != nil {
+		m.hint = fmt.Sprintf("创建群组异常: %v", err)
+		return m, nil
+	} else if groupRes.Code < 0 {
+		m.hint = fmt.Sprintf("创建群组异常: %d", groupRes.Code)
+		return m, nil
+	}
+
+	chat := initialChat(groupRes.Group.Name, groupRes.Group.Id, m.ui_base_t)
+	return chat, chat.Init()
+}
+
+type ui_group_t struct {
+	ui_form_t
+}
+
+func initialGroup(base ui_base_t) ui_group_t {
+	m := initialForm(
+		base,
+		2,
+		[]string{"群组名称", "群成员"},
+		"创建",
+		[]check_fn{groupNameLenCheck, membersLenCheck},
+		groupSubmit,
+	)
+
+	var t textinput.Model
+	for i := range m.inputs {
+		t = textinput.New()
+		t.CursorStyle = cursorStyle
+		t.CharLimit = 32
+
+		switch i {
+		case 0:
+			t.Placeholder = "gophers"
+			t.Focus()
+			t.PromptStyle = focusedStyle
+			t.TextStyle = focusedStyle
+		case 1:
+			t.Placeholder = "jack, rose"
+			t.CharLimit = 256
+			t.Validate = membersValidator
+		}
+
+		m.inputs[i] = t
+	}
+
+	return ui_group_t{ui_form_t: m}
+}
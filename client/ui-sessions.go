@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/muesli/reflow/indent"
+)
+
+// 登录 session 管理页面，可以撤销其他设备的登录
+type ui_sessions_t struct {
+	ui_base_t
+	sessions []*lib.Session
+	choice   int
+	hint     string
+}
+
+func initialSessions(base ui_base_t) ui_sessions_t {
+	m := ui_sessions_t{ui_base_t: base}
+	m.load()
+	return m
+}
+
+// 从服务器加载 session 列表
+func (m *ui_sessions_t) load() {
+	sessionsRes := &lib.SessionsRes{}
+	if err := m.poster.Handle(&lib.Sessions{}, sessionsRes); err != nil {
+		m.hint = fmt.Sprintf("获取登录设备异常: %v", err)
+		return
+	} else if sessionsRes.Code < 0 {
+		m.hint = fmt.Sprintf("获取登录设备异常: %d", sessionsRes.Code)
+		return
+	}
+
+	m.sessions = sessionsRes.Sessions
+	if m.choice > len(m.sessions)-1 {
+		m.choice = len(m.sessions) - 1
+	}
+	if m.choice < 0 {
+		m.choice = 0
+	}
+}
+
+// 撤销 session
+func (m *ui_sessions_t) revoke(revoke *lib.Revoke) {
+	revokeRes := &lib.RevokeRes{}
+	if err := m.poster.Handle(revoke, revokeRes); err != nil {
+		m.hint = fmt.Sprintf("撤销登录异常: %v", err)
+		return
+	} else if revokeRes.Code < 0 {
+		m.hint = fmt.Sprintf("撤销登录异常: %d", revokeRes.Code)
+		return
+	}
+
+	m.hint = fmt.Sprintf("已撤销 %d 个登录", len(revokeRes.Ids))
+	m.load()
+}
+
+func (m ui_sessions_t) Init() tea.Cmd {
+	return nil
+}
+
+func (m ui_sessions_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch keypress := msg.String(); keypress {
+		case "q", tea.KeyEsc.String(), tea.KeyCtrlC.String():
+			return m, tea.Quit
+		case tea.KeyCtrlR.String():
+			users := initialUsers(m.ui_base_t)
+			return users, users.Init()
+		case "j", tea.KeyDown.String():
+			if m.choice < len(m.sessions)-1 {
+				m.choice++
+			}
+		case "k", tea.KeyUp.String():
+			if m.choice > 0 {
+				m.choice--
+			}
+		case "d":
+			// 当前登录请使用 ctrl+x 登出
+			if m.choice < len(m.sessions) && !m.sessions[m.choice].Current {
+				m.revoke(&lib.Revoke{Id: m.sessions[m.choice].Id})
+			}
+		case "a":
+			m.revoke(&lib.Revoke{Others: true})
+		}
+	}
+	return m, nil
+}
+
+func (m ui_sessions_t) View() string {
+	lines := make([]string, len(m.sessions))
+	for i, s := range m.sessions {
+		label := fmt.Sprintf(
+			"#%d 登录于 %s，最近刷新 %s",
+			s.Id,
+			time.Unix(s.Created, 0).Format("2006-01-02 15:04"),
+			time.Unix(s.Refreshed, 0).Format("2006-01-02 15:04"),
+		)
+		if s.Current {
+			label += " (当前设备)"
+		}
+		lines[i] = checkbox(label, i == m.choice)
+	}
+
+	help := subtle("↑/k up") + dot + subtle("↓/j down") + dot + subtle("d revoke") + dot + subtle("a revoke others") + dot + subtle("ctrl+r back") + dot + subtle("q/esc quit")
+
+	s := fmt.Sprintf(
+		"%s\n\n%s\n\n%s",
+		inputStyle.Render("登录设备"),
+		strings.Join(lines, "\n"),
+		help,
+	)
+	if len(m.hint) > 0 {
+		s += "\n\n" + subtle(m.hint)
+	}
+	return indent.String("\n"+s+"\n\n", 4)
+}
+
+var _ tea.Model = (*ui_sessions_t)(nil)
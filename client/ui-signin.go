@@ -10,6 +10,10 @@ import (
 )
 
 func signinSubmit(m *ui_form_t) (tea.Model, tea.Cmd) {
+	if !passphraseCheck(m, 1, 2) {
+		return m, nil
+	}
+
 	passhash := sha256.Sum256([]byte(m.inputs[1].Value()))
 
 	tokenRes := &lib.TokenRes{}
@@ -28,6 +32,9 @@ func signinSubmit(m *ui_form_t) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// 同步端到端加密身份密钥，失败时仍可使用非加密聊天，本机没有身份私钥时聊天窗口会提示使用备份口令重新登录
+	syncIdentity(m.poster, m.storage, tokenRes.Username, m.inputs[2].Value())
+
 	users := initialUsers(m.ui_base_t)
 	return users, users.Init()
 }
@@ -39,10 +46,10 @@ type ui_signin_t struct {
 func initialSignin(base ui_base_t) ui_signin_t {
 	m := initialForm(
 		base,
-		2,
-		[]string{"用户名", "密码"},
+		3,
+		[]string{"用户名", "密码", "备份口令"},
 		"登录",
-		[]check_fn{usernameLenCheck, passwordLenCheck},
+		[]check_fn{usernameLenCheck, passwordLenCheck, passphraseLenCheck},
 		signinSubmit,
 	)
 
@@ -65,6 +72,8 @@ func initialSignin(base ui_base_t) ui_signin_t {
 			t.EchoMode = textinput.EchoPassword
 			t.EchoCharacter = '•'
 			t.Validate = passwordValidator
+		case 2:
+			t = passphraseInput()
 		}
 
 		m.inputs[i] = t
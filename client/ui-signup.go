@@ -15,6 +15,9 @@ func signupSubmit(m *ui_form_t) (tea.Model, tea.Cmd) {
 		m.errs[2] = m.errs[1]
 		return m, nil
 	}
+	if !passphraseCheck(m, 1, 3) {
+		return m, nil
+	}
 
 	passhash := sha256.Sum256([]byte(m.inputs[1].Value()))
 
@@ -34,6 +37,9 @@ func signupSubmit(m *ui_form_t) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// 同步端到端加密身份密钥，失败时仍可使用非加密聊天，本机没有身份私钥时聊天窗口会提示使用备份口令重新登录
+	syncIdentity(m.poster, m.storage, tokenRes.Username, m.inputs[3].Value())
+
 	users := initialUsers(m.ui_base_t)
 	return users, users.Init()
 }
@@ -45,13 +51,14 @@ type ui_signup_t struct {
 func initialSignup(base ui_base_t) ui_signup_t {
 	m := initialForm(
 		base,
-		3,
-		[]string{"用户名", "密码", "确认密码"},
+		4,
+		[]string{"用户名", "密码", "确认密码", "备份口令"},
 		"注册",
 		[]check_fn{
 			usernameLenCheck,
 			passwordLenCheck,
 			passwordLenCheck,
+			passphraseLenCheck,
 		},
 		signupSubmit,
 	)
@@ -80,6 +87,8 @@ func initialSignup(base ui_base_t) ui_signup_t {
 			t.EchoMode = textinput.EchoPassword
 			t.EchoCharacter = '•'
 			t.Validate = passwordValidator
+		case 3:
+			t = passphraseInput()
 		}
 
 		m.inputs[i] = t
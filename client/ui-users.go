@@ -27,9 +27,14 @@ var (
 )
 
 type item_t struct {
+	// 用户名或群组名称
 	username string
+	// 群组 id，为 0 时表示用户
+	group    uint64
 	online   bool
 	msgCount uint32
+	// 机器人帐号，用户名后显示 [bot]
+	bot bool
 }
 
 func (i item_t) FilterValue() string { return i.username }
@@ -47,7 +52,13 @@ func (d item_proxy_t) Render(w io.Writer, m list.Model, index int, listItem list
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("%d. ", index+1))
+	if i.group > 0 {
+		sb.WriteRune('#')
+	}
 	sb.WriteString(i.username)
+	if i.bot {
+		sb.WriteString(" [bot]")
+	}
 	if i.online {
 		sb.WriteRune('↑')
 	}
@@ -78,16 +89,35 @@ func newList(poster lib.Post, storage *storage_t) list.Model {
 		lib.FatalNotNil(fmt.Errorf("获取用户列表异常: %d", usersRes.Code))
 	}
 
+	groupsRes := &lib.GroupsRes{}
+	if err := poster.Handle(&lib.Groups{}, groupsRes); err != nil {
+		lib.FatalNotNil(err)
+	} else if groupsRes.Code < 0 {
+		lib.FatalNotNil(fmt.Errorf("获取群组列表异常: %d", groupsRes.Code))
+	}
+
 	unReadMsgCnt, err := storage.UnReadMsgCount()
 	lib.FatalNotNil(err)
 
-	items := make([]list.Item, len(usersRes.Users))
+	unReadGroupMsgCnt, err := storage.UnReadGroupMsgCount()
+	lib.FatalNotNil(err)
+
+	items := make([]list.Item, 0, len(usersRes.Users)+len(groupsRes.Groups))
 	for i := range usersRes.Users {
-		items[i] = item_t{
+		items = append(items, item_t{
 			username: usersRes.Users[i].Username,
 			online:   usersRes.Users[i].Online,
+			bot:      usersRes.Users[i].Bot,
 			msgCount: unReadMsgCnt[usersRes.Users[i].Username],
-		}
+		})
+	}
+	// 群组显示在用户后面
+	for i := range groupsRes.Groups {
+		items = append(items, item_t{
+			username: groupsRes.Groups[i].Name,
+			group:    groupsRes.Groups[i].Id,
+			msgCount: unReadGroupMsgCnt[groupsRes.Groups[i].Id],
+		})
 	}
 
 	l := list.New(items, item_proxy_t{}, listWidth, listHeight)
@@ -133,8 +163,14 @@ func (m ui_users_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if !ok {
 				return m, tea.Quit
 			}
-			chat := initialChat(i.username, m.ui_base_t)
+			chat := initialChat(i.username, i.group, m.ui_base_t)
 			return chat, chat.Init()
+		case tea.KeyCtrlN.String():
+			group := initialGroup(m.ui_base_t)
+			return group, group.Init()
+		case tea.KeyCtrlS.String():
+			sessions := initialSessions(m.ui_base_t)
+			return sessions, sessions.Init()
 		}
 
 	case tick_msg_t:
@@ -143,6 +179,11 @@ func (m ui_users_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		unReadGroupMsgCnt, err := m.storage.UnReadGroupMsgCount()
+		if err != nil {
+			return m, nil
+		}
+
 		pushes, err := m.storage.GetOnlinePushes()
 		if err != nil {
 			return m, nil
@@ -153,8 +194,18 @@ func (m ui_users_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i := range m.list.Items() {
 			v := m.list.Items()[i].(item_t)
 
-			count, hasUnReadMsg := unReadMsgCnt[v.username]
-			on, hasOnlinePush := pushes[v.username]
+			var (
+				count         uint32
+				on            bool
+				hasUnReadMsg  bool
+				hasOnlinePush bool
+			)
+			if v.group > 0 {
+				count, hasUnReadMsg = unReadGroupMsgCnt[v.group]
+			} else {
+				count, hasUnReadMsg = unReadMsgCnt[v.username]
+				on, hasOnlinePush = pushes[v.username]
+			}
 
 			if !(hasUnReadMsg || hasOnlinePush) {
 				continue loop
@@ -162,7 +213,9 @@ func (m ui_users_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			item := item_t{
 				username: v.username,
+				group:    v.group,
 				online:   v.online,
+				bot:      v.bot,
 				msgCount: v.msgCount,
 			}
 
@@ -188,7 +241,7 @@ func (m ui_users_t) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m ui_users_t) View() string {
-	help := subtle("↑/k up") + dot + subtle("↓/j down") + dot + subtle("q/esc quit") + dot + subtle("ctrl+x sign out") + dot + subtle("? more")
+	help := subtle("↑/k up") + dot + subtle("↓/j down") + dot + subtle("q/esc quit") + dot + subtle("ctrl+n new group") + dot + subtle("ctrl+s sessions") + dot + subtle("ctrl+x sign out") + dot + subtle("? more")
 
 	s := fmt.Sprintf(
 		"\n%s\n%s\n\n",
@@ -0,0 +1,260 @@
+// GoChat 机器人运行时。Bot 使用机器人帐号登录服务器，收到的文本消息按照前缀或正则匹配路由到命令处理函数，处理函数可以直接回复消息
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/huoyijie/GoChat/lib/client"
+)
+
+// 机器人收到的一条命令
+type Request struct {
+	// 收到的消息
+	Msg *lib.Msg
+	// 消息文本，已去掉首尾空白
+	Text string
+	// 按前缀路由时为前缀之后的参数，已去掉首尾空白
+	Args string
+	// 按正则路由时为匹配结果，Match[0] 为完整匹配
+	Match []string
+
+	bot *Bot
+}
+
+// 回复消息，群消息回复到群里，否则回复给发送者
+func (r *Request) Reply(ctx context.Context, text string) error {
+	if r.Msg.Group > 0 {
+		return r.bot.SendGroup(ctx, r.Msg.Group, text)
+	}
+	return r.bot.Send(ctx, r.Msg.From, text)
+}
+
+// 命令处理函数，返回的 error 会写入日志
+type HandlerFunc func(ctx context.Context, req *Request) error
+
+// 命令路由，prefix 和 re 只设置一个
+type route_t struct {
+	prefix  string
+	re      *regexp.Regexp
+	help    string
+	handler HandlerFunc
+}
+
+// 判断消息是否匹配当前路由，匹配时填充 req.Args 或 req.Match
+func (r *route_t) match(req *Request) bool {
+	if r.re != nil {
+		req.Match = r.re.FindStringSubmatch(req.Text)
+		return req.Match != nil
+	}
+
+	// 前缀之后必须是空白或者结尾，/echo 不会匹配 /echoes
+	if !strings.HasPrefix(req.Text, r.prefix) {
+		return false
+	}
+	args := strings.TrimPrefix(req.Text, r.prefix)
+	if len(args) > 0 && !strings.ContainsAny(args[:1], " \t\n") {
+		return false
+	}
+	req.Args = strings.TrimSpace(args)
+	return true
+}
+
+// 机器人
+type Bot struct {
+	c        *client.Client
+	username string
+	password string
+
+	mu       sync.RWMutex
+	routes   []*route_t
+	fallback HandlerFunc
+}
+
+// 创建机器人，username 和 password 为机器人帐号，帐号不存在时 Run 会自动注册机器人帐号
+func New(opts client.Options, username, password string) *Bot {
+	b := &Bot{
+		c:        client.New(opts),
+		username: username,
+		password: password,
+	}
+	b.Handle("/help", "显示帮助", b.help)
+	return b
+}
+
+// 返回底层客户端，可用于主动发送通知等
+func (b *Bot) Client() *client.Client {
+	return b.c
+}
+
+// 注册前缀命令，如 /echo。消息文本为 prefix 或者以 prefix 加空白开头时匹配，help 为 /help 中显示的说明
+func (b *Bot) Handle(prefix, help string, handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.routes = append(b.routes, &route_t{prefix: prefix, help: help, handler: handler})
+}
+
+// 注册正则命令，消息文本匹配 re 时调用 handler
+func (b *Bot) HandleRegex(re *regexp.Regexp, help string, handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.routes = append(b.routes, &route_t{re: re, help: help, handler: handler})
+}
+
+// 私聊消息没有匹配任何命令时调用 handler，群消息只处理命令。没有设置时只对以 / 开头的消息回复帮助提示，避免机器人之间互相回复
+func (b *Bot) Default(handler HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fallback = handler
+}
+
+// 发送文本消息给用户
+func (b *Bot) Send(ctx context.Context, to, text string) error {
+	_, err := b.c.Send(ctx, &lib.Msg{Kind: lib.MsgKind_TEXT, From: b.username, To: to, Data: []byte(text)})
+	return err
+}
+
+// 发送文本消息到群组，机器人需要是群成员
+func (b *Bot) SendGroup(ctx context.Context, group uint64, text string) error {
+	_, err := b.c.Send(ctx, &lib.Msg{Kind: lib.MsgKind_TEXT, From: b.username, Group: group, Data: []byte(text)})
+	return err
+}
+
+// 登录机器人帐号，帐号不存在时注册
+func (b *Bot) signin(ctx context.Context) error {
+	_, err := b.c.Signin(ctx, b.username, b.password)
+	var serverErr *client.ServerError
+	if errors.As(err, &serverErr) && serverErr.Code == lib.Err_Acc_Not_Exist.Val() {
+		_, err = b.c.SignupBot(ctx, b.username, b.password)
+	}
+	return err
+}
+
+// 连接服务器并登录，然后处理收到的消息，直到 ctx 取消或者客户端关闭。返回前会等待正在执行的处理函数
+func (b *Bot) Run(ctx context.Context) (err error) {
+	defer b.c.Close()
+
+	if err = b.c.Connect(ctx); err != nil {
+		return
+	}
+	if err = b.signin(ctx); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-b.c.Done():
+			return client.ErrClosed
+
+		case msg := <-b.c.Msgs():
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.dispatch(ctx, msg)
+			}()
+
+		// 机器人不关心上下线和回执等 push
+		case <-b.c.Pushes():
+
+		// 使用密码登录，不需要保存 token
+		case <-b.c.Tokens():
+
+		case err := <-b.c.Errs():
+			var versionErr *client.VersionError
+			if errors.As(err, &versionErr) {
+				return err
+			}
+			// 登录被撤销后使用密码重新登录
+			var serverErr *client.ServerError
+			if errors.As(err, &serverErr) && serverErr.Code == lib.Err_Token_Revoked.Val() {
+				if err := b.signin(ctx); err != nil {
+					slog.Warn("bot signin", "username", b.username, "err", err)
+				}
+				continue
+			}
+			slog.Warn("bot connection error", "username", b.username, "err", err)
+		}
+	}
+}
+
+// 把消息路由到第一个匹配的处理函数
+func (b *Bot) dispatch(ctx context.Context, msg *lib.Msg) {
+	// 忽略自己从其他设备发送的消息
+	if msg.From == b.username {
+		return
+	}
+
+	// 私聊消息回复已读回执，重新登录后服务器不会再转发这些消息
+	if msg.Group == 0 {
+		if err := b.c.Notify(&lib.Receipt{Status: lib.MsgStatus_READ, Ids: []int64{msg.Id}}); err != nil {
+			slog.Warn("bot receipt", "username", b.username, "msg_id", msg.Id, "err", err)
+		}
+	}
+
+	// 机器人没有发布公钥，不会收到加密消息
+	if msg.Kind != lib.MsgKind_TEXT {
+		return
+	}
+
+	req := &Request{Msg: msg, Text: strings.TrimSpace(string(msg.Data)), bot: b}
+	handler := b.route(req)
+	if handler == nil {
+		return
+	}
+	if err := handler(ctx, req); err != nil {
+		slog.Error("bot handle", "username", b.username, "from", msg.From, "group", msg.Group, "text", req.Text, "err", err)
+	}
+}
+
+// 查找处理函数，没有匹配时私聊消息使用 fallback
+func (b *Bot) route(req *Request) HandlerFunc {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, r := range b.routes {
+		if r.match(req) {
+			return r.handler
+		}
+	}
+
+	if req.Msg.Group > 0 {
+		return nil
+	}
+	if b.fallback != nil {
+		return b.fallback
+	}
+	if !strings.HasPrefix(req.Text, "/") {
+		return nil
+	}
+	return func(ctx context.Context, req *Request) error {
+		return req.Reply(ctx, "未知命令，发送 /help 查看可用命令")
+	}
+}
+
+// 回复已注册的命令列表
+func (b *Bot) help(ctx context.Context, req *Request) error {
+	b.mu.RLock()
+	var sb strings.Builder
+	sb.WriteString("可用命令:")
+	for _, r := range b.routes {
+		if r.re != nil {
+			fmt.Fprintf(&sb, "\n  %s  %s", r.re, r.help)
+		} else {
+			fmt.Fprintf(&sb, "\n  %s  %s", r.prefix, r.help)
+		}
+	}
+	b.mu.RUnlock()
+	return req.Reply(ctx, sb.String())
+}
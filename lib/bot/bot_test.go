@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/huoyijie/GoChat/lib/client"
+)
+
+func TestRouteMatch(t *testing.T) {
+	echo := &route_t{prefix: "/echo"}
+	weather := &route_t{re: regexp.MustCompile(`^天气\s+(\S+)$`)}
+
+	for _, c := range []struct {
+		route *route_t
+		text  string
+		ok    bool
+		args  string
+		match []string
+	}{
+		{echo, "/echo", true, "", nil},
+		{echo, "/echo hello  world", true, "hello  world", nil},
+		{echo, "/echo\tx", true, "x", nil},
+		{echo, "/echo\nx", true, "x", nil},
+		{echo, "/echoes", false, "", nil},
+		{echo, "echo x", false, "", nil},
+		{echo, "/ech", false, "", nil},
+		{weather, "天气 北京", true, "", []string{"天气 北京", "北京"}},
+		{weather, "天气", false, "", nil},
+		{weather, "明天天气 北京", false, "", nil},
+	} {
+		req := &Request{Text: c.text}
+		if ok := c.route.match(req); ok != c.ok || req.Args != c.args || !reflect.DeepEqual(req.Match, c.match) {
+			t.Errorf("match(%q) = %v, args %q, match %q; want %v, %q, %q", c.text, ok, req.Args, req.Match, c.ok, c.args, c.match)
+		}
+	}
+}
+
+// 创建不连接服务器的机器人，注册 /echo 和正则命令，返回记录被调用命令的 channel
+func newTestBot(t *testing.T) (*Bot, chan string) {
+	t.Helper()
+	b := New(client.Options{}, "echobot", "hello123")
+	t.Cleanup(func() { b.c.Close() })
+	called := make(chan string, 1)
+	b.Handle("/echo", "回显", func(ctx context.Context, req *Request) error {
+		called <- "echo:" + req.Args
+		return nil
+	})
+	b.HandleRegex(regexp.MustCompile(`^(\d+)\+(\d+)$`), "加法", func(ctx context.Context, req *Request) error {
+		called <- "add:" + req.Match[1] + "," + req.Match[2]
+		return nil
+	})
+	return b, called
+}
+
+// 按注册顺序匹配命令，没有匹配时私聊消息使用 fallback 或者对 / 开头的消息回复帮助提示，群消息不处理
+func TestRoute(t *testing.T) {
+	b, _ := newTestBot(t)
+
+	for _, c := range []struct {
+		text  string
+		group uint64
+		found bool
+	}{
+		{"/echo hi", 0, true},
+		{"/echo hi", 1, true},
+		{"1+2", 1, true},
+		{"/help", 0, true},
+		// 未知命令只在私聊中回复帮助提示
+		{"/unknown", 0, true},
+		{"/unknown", 1, false},
+		// 没有设置 fallback 时不回复普通消息，避免机器人之间互相回复
+		{"hello", 0, false},
+		{"hello", 1, false},
+	} {
+		req := &Request{Msg: &lib.Msg{From: "alice", Group: c.group}, Text: c.text, bot: b}
+		if found := b.route(req) != nil; found != c.found {
+			t.Errorf("route(%q, group %d) found = %v, want %v", c.text, c.group, found, c.found)
+		}
+	}
+
+	var fallback bool
+	b.Default(func(ctx context.Context, req *Request) error {
+		fallback = true
+		return nil
+	})
+	for _, c := range []struct {
+		text  string
+		group uint64
+		found bool
+	}{
+		{"hello", 0, true},
+		{"/unknown", 0, true},
+		{"hello", 1, false},
+	} {
+		req := &Request{Msg: &lib.Msg{From: "alice", Group: c.group}, Text: c.text, bot: b}
+		handler := b.route(req)
+		if found := handler != nil; found != c.found {
+			t.Errorf("with fallback route(%q, group %d) found = %v, want %v", c.text, c.group, found, c.found)
+			continue
+		}
+		if handler != nil {
+			fallback = false
+			handler(context.Background(), req)
+			if !fallback {
+				t.Errorf("route(%q) should use fallback", c.text)
+			}
+		}
+	}
+}
+
+// dispatch 忽略自己的消息和非文本消息，文本消息去掉首尾空白后路由
+func TestDispatch(t *testing.T) {
+	b, called := newTestBot(t)
+	ctx := context.Background()
+
+	for _, c := range []struct {
+		msg  *lib.Msg
+		want string
+	}{
+		{&lib.Msg{Id: 1, Kind: lib.MsgKind_TEXT, From: "alice", To: "echobot", Data: []byte("  /echo hi \n")}, "echo:hi"},
+		{&lib.Msg{Id: 2, Kind: lib.MsgKind_TEXT, From: "alice", Group: 1, Data: []byte("3+4")}, "add:3,4"},
+		// 自己从其他设备发送的消息
+		{&lib.Msg{Id: 3, Kind: lib.MsgKind_TEXT, From: "echobot", To: "alice", Data: []byte("/echo loop")}, ""},
+		// 加密消息
+		{&lib.Msg{Id: 4, Kind: lib.MsgKind_SEALED, From: "alice", To: "echobot", Data: []byte("/echo sealed")}, ""},
+	} {
+		b.dispatch(ctx, c.msg)
+		var got string
+		select {
+		case got = <-called:
+		default:
+		}
+		if got != c.want {
+			t.Errorf("dispatch(%q from %s) called %q, want %q", c.msg.Data, c.msg.From, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+// GoChat 客户端 SDK。Client 负责连接服务器、协议握手、心跳、同步请求和断线重连，服务器发送的消息和 push 通过 channel 返回
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// 同步请求默认超时时间，ctx 没有设置 deadline 时使用
+	DEFAULT_TIMEOUT = 5 * time.Second
+	// 默认拨号超时时间
+	DEFAULT_DIAL_TIMEOUT = 3 * time.Second
+	// 首次连接默认最多重试次数
+	DEFAULT_MAX_RETRIES = 15
+	// 发送 ping 的时间间隔
+	PING_INTERVAL = 20 * time.Second
+	// 发送 ping 后等待 pong 的最长时间
+	PONG_TIMEOUT = 10 * time.Second
+	// 事件 channel 长度
+	EVENT_BUFFER = 1024
+)
+
+var (
+	// 客户端已关闭
+	ErrClosed = errors.New("client closed")
+	// 等待响应时连接已断开
+	ErrDisconnected = errors.New("connection lost")
+)
+
+// 服务器返回的错误码
+type ServerError struct {
+	Code int32
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error: %d", e.Code)
+}
+
+// 客户端协议版本低于服务器支持的最低版本，不会再重新连接
+type VersionError struct {
+	MinVersion uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("客户端版本过低，协议版本 %d，服务器要求最低版本 %d，请升级客户端", lib.PROTO_VERSION, e.MinVersion)
+}
+
+// 客户端配置
+type Options struct {
+	// 服务器地址
+	Addr string
+	// tls 配置，为 nil 时不使用 tls
+	TLS *tls.Config
+	// 拨号超时时间，默认 DEFAULT_DIAL_TIMEOUT
+	DialTimeout time.Duration
+	// 首次连接最多重试次数，默认 DEFAULT_MAX_RETRIES。之后断线时会一直重试，直到客户端关闭
+	MaxRetries int
+}
+
+// GoChat 客户端。Connect 成功后在后台维持连接，断开后按照指数回退策略自动重新连接，并使用最近一次登录得到的 token 重新登录
+//
+// 调用方需要持续读取 Msgs 和 Pushes，否则会阻塞接收后续 packet。Tokens 和 Errs 只保留最近的事件
+type Client struct {
+	opts    Options
+	reqChan chan *request_t
+	msgs    chan *lib.Msg
+	pushes  chan *lib.Push
+	tokens  chan *lib.TokenRes
+	errs    chan error
+
+	mu sync.Mutex
+	// 当前 token，重新连接后使用该 token 自动登录
+	token []byte
+	// 最近一次握手结果
+	hello *lib.HelloRes
+
+	// Connect 正在连接
+	connecting bool
+	// Connect 已经连接成功，之后由后台协程维持连接
+	connected bool
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// 创建客户端，需要调用 Connect 连接服务器
+func New(opts Options) *Client {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = DEFAULT_DIAL_TIMEOUT
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = DEFAULT_MAX_RETRIES
+	}
+	return &Client{
+		opts:    opts,
+		reqChan: make(chan *request_t, EVENT_BUFFER),
+		msgs:    make(chan *lib.Msg, EVENT_BUFFER),
+		pushes:  make(chan *lib.Push, EVENT_BUFFER),
+		tokens:  make(chan *lib.TokenRes, 1),
+		errs:    make(chan error, 16),
+		closed:  make(chan struct{}),
+	}
+}
+
+// 服务器转发的新消息，包括自己从其他设备发送的消息
+func (c *Client) Msgs() <-chan *lib.Msg {
+	return c.msgs
+}
+
+// 服务器发送的 push，包括上下线提醒和消息回执
+func (c *Client) Pushes() <-chan *lib.Push {
+	return c.pushes
+}
+
+// 重新连接后自动登录时刷新的 token，旧 token 已失效，调用方需要保存新 token
+func (c *Client) Tokens() <-chan *lib.TokenRes {
+	return c.tokens
+}
+
+// 连接上发生的错误，例如 *ServerError (token 已撤销等) 和 *VersionError
+func (c *Client) Errs() <-chan error {
+	return c.errs
+}
+
+// 客户端关闭后关闭该 channel
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// 最近一次握手时服务器返回的版本、特性等信息
+func (c *Client) Hello() *lib.HelloRes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hello
+}
+
+// 连接服务器并完成握手，失败时按照指数回退策略重试 MaxRetries 次。协议版本不兼容时不再重试
+func (c *Client) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	if c.connected || c.connecting {
+		c.mu.Unlock()
+		return errors.New("already connected")
+	}
+	c.connecting = true
+	c.mu.Unlock()
+
+	// 连接成功后才设置 connected，失败后可以再次调用 Connect
+	defer func() {
+		c.mu.Lock()
+		c.connecting = false
+		c.mu.Unlock()
+	}()
+
+	var err error
+	for i := 0; i < c.opts.MaxRetries; i++ {
+		var cn *conn_t
+		if cn, err = c.dial(ctx); err == nil {
+			c.mu.Lock()
+			c.connected = true
+			c.mu.Unlock()
+			go c.run(cn)
+			return nil
+		}
+
+		var versionErr *VersionError
+		if errors.As(err, &versionErr) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.closed:
+			return ErrClosed
+		case <-time.After(backoff(i)):
+		}
+	}
+	return fmt.Errorf("connect error: %w", err)
+}
+
+// 关闭客户端，断开连接
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// 发送同步请求，并把服务器响应解析到 res。ctx 没有设置 deadline 时使用 DEFAULT_TIMEOUT
+//
+// 响应为 TokenRes 且登录成功时，会记录 token 用于重新连接后自动登录
+func (c *Client) Do(ctx context.Context, req, res proto.Message) error {
+	kind, err := requestToKind(req)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := lib.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DEFAULT_TIMEOUT)
+		defer cancel()
+	}
+
+	request := newRequest(ctx, &lib.Packet{Kind: kind, Data: bytes}, true)
+	if err := c.post(ctx, request); err != nil {
+		return err
+	}
+
+	select {
+	case pack := <-request.c:
+		if pack == nil {
+			return ErrDisconnected
+		}
+		if err := lib.Unmarshal(pack.Data, res); err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("%s 请求超时: %w", kind, ctx.Err())
+	case <-c.closed:
+		return ErrClosed
+	}
+
+	switch res := res.(type) {
+	case *lib.TokenRes:
+		if res.Code == 0 {
+			c.setToken(res.Token)
+		}
+	case *lib.SignoutRes:
+		if res.Code == 0 {
+			c.setToken(nil)
+		}
+	}
+	return nil
+}
+
+// 发送非同步请求，例如消息回执
+func (c *Client) Notify(req proto.Message) error {
+	kind, err := requestToKind(req)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := lib.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return c.post(context.Background(), newRequest(context.Background(), &lib.Packet{Kind: kind, Data: bytes}, false))
+}
+
+// 把请求交给发送协程，断线期间请求会等待重新连接
+func (c *Client) post(ctx context.Context, request *request_t) error {
+	select {
+	case c.reqChan <- request:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return ErrClosed
+	}
+}
+
+// 注册帐号，password 为明文密码
+func (c *Client) Signup(ctx context.Context, username, password string) (*lib.TokenRes, error) {
+	tokenRes := &lib.TokenRes{}
+	if err := c.Do(ctx, &lib.Signup{Auth: auth(username, password)}, tokenRes); err != nil {
+		return nil, err
+	}
+	return tokenRes, codeErr(tokenRes.Code)
+}
+
+// 注册机器人帐号，其他用户的用户列表中会标记为机器人
+func (c *Client) SignupBot(ctx context.Context, username, password string) (*lib.TokenRes, error) {
+	tokenRes := &lib.TokenRes{}
+	if err := c.Do(ctx, &lib.Signup{Auth: auth(username, password), Bot: true}, tokenRes); err != nil {
+		return nil, err
+	}
+	return tokenRes, codeErr(tokenRes.Code)
+}
+
+// 登录，password 为明文密码
+func (c *Client) Signin(ctx context.Context, username, password string) (*lib.TokenRes, error) {
+	tokenRes := &lib.TokenRes{}
+	if err := c.Do(ctx, &lib.Signin{Auth: auth(username, password)}, tokenRes); err != nil {
+		return nil, err
+	}
+	return tokenRes, codeErr(tokenRes.Code)
+}
+
+// 使用之前保存的 token 登录。验证成功后服务器会刷新 token，旧 token 失效
+func (c *Client) ValidateToken(ctx context.Context, token []byte) (*lib.TokenRes, error) {
+	tokenRes := &lib.TokenRes{}
+	if err := c.Do(ctx, &lib.Token{Token: token}, tokenRes); err != nil {
+		return nil, err
+	}
+	return tokenRes, codeErr(tokenRes.Code)
+}
+
+// 获取用户列表
+func (c *Client) Users(ctx context.Context) ([]*lib.User, error) {
+	usersRes := &lib.UsersRes{}
+	if err := c.Do(ctx, &lib.Users{}, usersRes); err != nil {
+		return nil, err
+	}
+	return usersRes.Users, codeErr(usersRes.Code)
+}
+
+// 发送消息，返回服务器生成的消息 id
+func (c *Client) Send(ctx context.Context, msg *lib.Msg) (int64, error) {
+	msgRes := &lib.MsgRes{}
+	if err := c.Do(ctx, msg, msgRes); err != nil {
+		return 0, err
+	}
+	return msgRes.Id, codeErr(msgRes.Code)
+}
+
+// 返回 lib.Post 接口，Handle 使用默认超时时间发送同步请求，Close 会关闭客户端
+func (c *Client) Post() lib.Post {
+	return &poster_t{c}
+}
+
+// 与服务端使用相同的密码摘要
+func auth(username, password string) *lib.Auth {
+	passhash := sha256.Sum256([]byte(password))
+	return &lib.Auth{Username: username, Passhash: passhash[:]}
+}
+
+// 错误码转换为 error
+func codeErr(code int32) error {
+	if code < 0 {
+		return &ServerError{code}
+	}
+	return nil
+}
+
+func (c *Client) getToken() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+func (c *Client) setToken(token []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// 发送 token 事件，只保留最新的 token
+func (c *Client) emitToken(tokenRes *lib.TokenRes) {
+	for {
+		select {
+		case c.tokens <- tokenRes:
+			return
+		default:
+		}
+		select {
+		case <-c.tokens:
+		default:
+		}
+	}
+}
+
+// 发送错误事件，channel 已满时丢弃
+func (c *Client) emitErr(err error) {
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+// 连接失败后的等待时间，指数增长并加上随机时间，最长 8s
+func backoff(i int) time.Duration {
+	p := math.Pow(2, float64(i))
+	r := float64(rand.Intn(1000))
+	return time.Duration(math.Min(p+r, 8000)) * time.Millisecond
+}
+
+// 拨号连接服务器并握手
+func (c *Client) dial(ctx context.Context) (*conn_t, error) {
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.opts.TLS == nil {
+		conn, err = dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	} else {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.opts.TLS}).DialContext(ctx, "tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cn := newConn(conn)
+	hello, err := cn.hello()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.hello = hello
+	c.mu.Unlock()
+	return cn, nil
+}
+
+// 连接断开后重新连接，并使用当前 token 自动登录。客户端关闭或协议版本不兼容时返回 nil
+func (c *Client) reconnect() *conn_t {
+	for i := 0; ; i++ {
+		cn, err := c.dial(context.Background())
+		if err == nil {
+			if err = c.reauth(cn); err == nil {
+				return cn
+			}
+			cn.Close()
+		}
+
+		var versionErr *VersionError
+		if errors.As(err, &versionErr) {
+			c.emitErr(err)
+			return nil
+		}
+
+		select {
+		case <-c.closed:
+			return nil
+		case <-time.After(backoff(i)):
+		}
+	}
+}
+
+// 使用当前 token 登录新连接，在开始发送其他请求之前完成。token 无效时清除 token 并发送错误事件
+func (c *Client) reauth(cn *conn_t) error {
+	token := c.getToken()
+	if token == nil {
+		return nil
+	}
+
+	tokenRes := &lib.TokenRes{}
+	if err := cn.roundTrip(lib.PackKind_TOKEN, &lib.Token{Token: token}, tokenRes); err != nil {
+		return err
+	}
+	if tokenRes.Code < 0 {
+		c.setToken(nil)
+		c.emitErr(&ServerError{tokenRes.Code})
+		return nil
+	}
+
+	c.setToken(tokenRes.Token)
+	c.emitToken(tokenRes)
+	return nil
+}
+
+// 维持连接，连接断开后重新连接，直到客户端关闭
+func (c *Client) run(cn *conn_t) {
+	for {
+		retryAfter, quit := c.serve(cn)
+		if quit {
+			return
+		}
+
+		// 服务器正常关闭，等待一段时间后再重新连接
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(retryAfter):
+		}
+
+		if cn = c.reconnect(); cn == nil {
+			return
+		}
+	}
+}
+
+// 在连接上收发 packet，连接断开时返回服务器建议的重连等待时间，客户端关闭时 quit 为 true
+func (c *Client) serve(cn *conn_t) (retryAfter time.Duration, quit bool) {
+	// 响应 channel
+	resChan := make(chan *lib.Packet, EVENT_BUFFER)
+	// 服务器关闭时通过该 channel 返回建议的重连等待时间
+	goAwayChan := make(chan time.Duration, 1)
+
+	// 启动单独的协程，接收处理或转发来自服务器的 packet
+	go c.recvFrom(cn, resChan, goAwayChan)
+
+	// 当前协程发送请求并接收响应
+	quit = c.sendTo(cn, resChan)
+
+	select {
+	case retryAfter = <-goAwayChan:
+	default:
+	}
+	return
+}
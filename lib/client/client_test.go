@@ -0,0 +1,261 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 测试用服务器，按照协议处理握手、登录、token 验证和消息请求
+type fake_server_t struct {
+	ln net.Listener
+
+	mu sync.Mutex
+	// 大于 PROTO_VERSION 时握手返回版本不兼容
+	minVersion uint32
+	// 收到的 HELLO 数量
+	hellos int
+	// 收到的 token，用于检查重新连接后是否使用 token 自动登录
+	tokens [][]byte
+	// 签发的 token 序号
+	serial int
+	conns  []net.Conn
+}
+
+func newFakeServer(t *testing.T) *fake_server_t {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fake_server_t{ln: ln}
+	t.Cleanup(func() {
+		ln.Close()
+		s.drop()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fake_server_t) setMinVersion(v uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minVersion = v
+}
+
+// 断开所有连接
+func (s *fake_server_t) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// 签发新 token
+func (s *fake_server_t) newToken(username string) *lib.TokenRes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serial++
+	return &lib.TokenRes{Id: 1, Username: username, Token: []byte(fmt.Sprintf("token-%d", s.serial))}
+}
+
+func (s *fake_server_t) serve(conn net.Conn) {
+	defer conn.Close()
+	r := lib.NewFrameReader(conn, lib.DEFAULT_MAX_FRAME_SIZE)
+	w := lib.NewFrameWriter(conn, lib.DEFAULT_MAX_FRAME_SIZE)
+	reply := func(pack *lib.Packet, kind lib.PackKind, res proto.Message) error {
+		bytes, err := lib.Marshal(res)
+		if err != nil {
+			return err
+		}
+		return w.WritePack(&lib.Packet{Id: pack.Id, Kind: kind, Data: bytes})
+	}
+
+	for {
+		pack := &lib.Packet{}
+		if err := r.ReadPack(pack); err != nil {
+			return
+		}
+
+		var err error
+		switch pack.Kind {
+		case lib.PackKind_HELLO:
+			s.mu.Lock()
+			s.hellos++
+			minVersion := s.minVersion
+			s.mu.Unlock()
+			if minVersion > lib.PROTO_VERSION {
+				err = reply(pack, lib.PackKind_RES, &lib.HelloRes{Code: lib.Err_Version_Unsupported.Val(), MinVersion: minVersion})
+			} else {
+				err = reply(pack, lib.PackKind_RES, &lib.HelloRes{Version: lib.PROTO_VERSION, MaxFrameSize: lib.DEFAULT_MAX_FRAME_SIZE})
+			}
+		case lib.PackKind_SIGNIN:
+			signin := &lib.Signin{}
+			if err = lib.Unmarshal(pack.Data, signin); err == nil {
+				err = reply(pack, lib.PackKind_RES, s.newToken(signin.Auth.Username))
+			}
+		case lib.PackKind_TOKEN:
+			token := &lib.Token{}
+			if err = lib.Unmarshal(pack.Data, token); err == nil {
+				s.mu.Lock()
+				s.tokens = append(s.tokens, token.Token)
+				s.mu.Unlock()
+				err = reply(pack, lib.PackKind_RES, s.newToken("alice"))
+			}
+		case lib.PackKind_MSG:
+			err = reply(pack, lib.PackKind_RES, &lib.MsgRes{Id: 42})
+		case lib.PackKind_PING:
+			err = reply(pack, lib.PackKind_PONG, &lib.Pong{})
+		// 不响应 USERS 请求，用于测试请求超时
+		case lib.PackKind_USERS:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// 连接测试服务器，测试结束后关闭客户端
+func connect(t *testing.T, s *fake_server_t) *Client {
+	t.Helper()
+	c := New(Options{Addr: s.ln.Addr().String()})
+	t.Cleanup(func() { c.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// 连接、握手、登录后发送消息
+func TestConnectSignin(t *testing.T) {
+	c := connect(t, newFakeServer(t))
+	if c.Hello() == nil || c.Hello().Version != lib.PROTO_VERSION {
+		t.Errorf("hello = %v", c.Hello())
+	}
+
+	ctx := context.Background()
+	tokenRes, err := c.Signin(ctx, "alice", "hello123")
+	if err != nil || string(tokenRes.Token) != "token-1" {
+		t.Fatalf("Signin = %v, %v", tokenRes, err)
+	}
+	if id, err := c.Send(ctx, &lib.Msg{To: "bob", Data: []byte("hi")}); err != nil || id != 42 {
+		t.Errorf("Send = %d, %v", id, err)
+	}
+	if err := c.Connect(ctx); err == nil {
+		t.Error("connecting twice should fail")
+	}
+}
+
+// 服务器没有响应时请求超时
+func TestDoTimeout(t *testing.T) {
+	c := connect(t, newFakeServer(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Users(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Users = %v, want deadline exceeded", err)
+	}
+
+	// 超时后连接仍然可用
+	if _, err := c.Send(context.Background(), &lib.Msg{To: "bob"}); err != nil {
+		t.Errorf("Send after timeout = %v", err)
+	}
+}
+
+// 服务器断开连接后自动重新连接，并使用最近一次登录的 token 重新登录
+func TestReconnect(t *testing.T) {
+	s := newFakeServer(t)
+	c := connect(t, s)
+	if _, err := c.Signin(context.Background(), "alice", "hello123"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.drop()
+	select {
+	case tokenRes := <-c.Tokens():
+		if string(tokenRes.Token) != "token-2" {
+			t.Errorf("refreshed token = %s", tokenRes.Token)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not reconnect")
+	}
+
+	s.mu.Lock()
+	tokens := s.tokens
+	s.mu.Unlock()
+	if len(tokens) != 1 || string(tokens[0]) != "token-1" {
+		t.Errorf("tokens replayed = %q, want [token-1]", tokens)
+	}
+	if _, err := c.Send(context.Background(), &lib.Msg{To: "bob"}); err != nil {
+		t.Errorf("Send after reconnect = %v", err)
+	}
+}
+
+// 协议版本不兼容时不再重试，之后可以再次连接
+func TestConnectVersionError(t *testing.T) {
+	s := newFakeServer(t)
+	s.setMinVersion(lib.PROTO_VERSION + 1)
+
+	c := New(Options{Addr: s.ln.Addr().String()})
+	defer c.Close()
+	err := c.Connect(context.Background())
+	var versionErr *VersionError
+	if !errors.As(err, &versionErr) || versionErr.MinVersion != lib.PROTO_VERSION+1 {
+		t.Fatalf("Connect = %v, want VersionError", err)
+	}
+	s.mu.Lock()
+	hellos := s.hellos
+	s.mu.Unlock()
+	if hellos != 1 {
+		t.Errorf("hellos = %d, version error should stop retries", hellos)
+	}
+
+	s.setMinVersion(0)
+	if err := c.Connect(context.Background()); err != nil {
+		t.Errorf("Connect after failure = %v", err)
+	}
+}
+
+// ctx 取消后 Connect 返回，之后可以再次连接
+func TestConnectCanceled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := New(Options{Addr: addr})
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Connect = %v, want deadline exceeded", err)
+	}
+	// 失败后没有被标记为已连接
+	if err := c.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Connect again = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 到服务器的一个连接
+type conn_t struct {
+	net.Conn
+	r *lib.FrameReader
+	w *lib.FrameWriter
+	// 已分配的 packet.id
+	id uint64
+}
+
+// 握手之前使用默认最大帧长度
+func newConn(conn net.Conn) *conn_t {
+	return &conn_t{
+		Conn: conn,
+		r:    lib.NewFrameReader(conn, lib.DEFAULT_MAX_FRAME_SIZE),
+		w:    lib.NewFrameWriter(conn, lib.DEFAULT_MAX_FRAME_SIZE),
+	}
+}
+
+// 分配 packet.id 并发送 packet
+func (cn *conn_t) send(pack *lib.Packet) error {
+	cn.id++
+	pack.Id = cn.id
+	return cn.w.WritePack(pack)
+}
+
+// 在启动收发协程之前同步发送请求并等待响应，服务器在响应之前不会发送其他 packet
+func (cn *conn_t) roundTrip(kind lib.PackKind, req, res proto.Message) (err error) {
+	cn.SetDeadline(time.Now().Add(DEFAULT_TIMEOUT))
+	defer cn.SetDeadline(time.Time{})
+
+	bytes, err := lib.Marshal(req)
+	if err != nil {
+		return
+	}
+	if err = cn.send(&lib.Packet{Kind: kind, Data: bytes}); err != nil {
+		return
+	}
+
+	pack := &lib.Packet{}
+	if err = cn.r.ReadPack(pack); err != nil {
+		return
+	}
+	if pack.Kind != lib.PackKind_RES && pack.Kind != lib.PackKind_ERR {
+		return fmt.Errorf("unexpected packet %v", pack.Kind)
+	}
+	return lib.Unmarshal(pack.Data, res)
+}
+
+// 与服务器握手，交换协议版本、支持的特性和构建信息，之后按照服务器返回的最大帧长度收发 packet
+func (cn *conn_t) hello() (helloRes *lib.HelloRes, err error) {
+	helloRes = &lib.HelloRes{}
+	if err = cn.roundTrip(lib.PackKind_HELLO, &lib.Hello{Version: lib.PROTO_VERSION, Features: lib.Features, Build: lib.Build}, helloRes); err != nil {
+		return nil, err
+	}
+	if helloRes.Code == lib.Err_Version_Unsupported.Val() {
+		return nil, &VersionError{helloRes.MinVersion}
+	}
+	if helloRes.Code < 0 {
+		return nil, fmt.Errorf("handshake error: %d", helloRes.Code)
+	}
+
+	// 旧版本服务器没有返回最大长度
+	if helloRes.MaxFrameSize == 0 {
+		helloRes.MaxFrameSize = lib.DEFAULT_MAX_FRAME_SIZE
+	}
+	cn.r = lib.NewFrameReader(cn.Conn, helloRes.MaxFrameSize)
+	cn.w = lib.NewFrameWriter(cn.Conn, helloRes.MaxFrameSize)
+	return
+}
+
+// 向服务器发送 packet。如果是同步请求，会通过 request.c 返回服务器响应数据，同时也会移除已超时的同步请求
+func (c *Client) sendTo(cn *conn_t, resChan <-chan *lib.Packet) (quit bool) {
+	// 从当前方法返回后，断开连接，清理资源等
+	defer cn.Close()
+
+	// 登记所有的同步请求，并等待响应
+	requests := make(map[uint64]*request_t)
+	// 连接断开时，等待响应的请求立即返回
+	defer func() {
+		for _, request := range requests {
+			request.c <- nil
+		}
+	}()
+
+	// 同步请求超时检查间隔 50ms
+	timeoutTicker := time.NewTicker(50 * time.Millisecond)
+	defer timeoutTicker.Stop()
+	pingTicker := time.NewTicker(PING_INTERVAL)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+
+		// 客户端已关闭
+		case <-c.closed:
+			quit = true
+			return
+
+		// 有服务器请求进来
+		case request := <-c.reqChan:
+			// 断线期间已经超时的请求不再发送
+			if request.ctx.Err() != nil {
+				continue
+			}
+
+			if err := cn.send(request.pack); err != nil {
+				// packet 过大时没有发送任何数据，直接返回错误码，不需要断开连接
+				var sizeErr *lib.FrameSizeError
+				if !errors.As(err, &sizeErr) { // 发送字节数据错误
+					if request.sync() {
+						request.c <- nil
+					}
+					return
+				}
+				if request.sync() {
+					bytes, err := lib.Marshal(&lib.ErrRes{Code: lib.Err_Frame_Too_Large.Val()})
+					if err != nil {
+						return
+					}
+					request.c <- &lib.Packet{Id: request.pack.Id, Kind: lib.PackKind_RES, Data: bytes}
+				}
+				continue
+			}
+
+			if request.sync() { // 登记同步请求
+				requests[request.pack.Id] = request
+			}
+
+		// 通过 resChan 接收从 recvFrom 协程发送过来的响应
+		case pack, ok := <-resChan:
+			if !ok { // recvFrom 协程已退出，连接已断开，需要重新连接和启动新协程
+				return
+			}
+
+			if request, found := requests[pack.Id]; found {
+				// 通过 request.c 返回服务器响应数据
+				request.c <- pack
+				// 删除登记的同步请求
+				delete(requests, pack.Id)
+			}
+
+		// 每隔 50ms 移除调用方已经不再等待的同步请求
+		case <-timeoutTicker.C:
+			for id, request := range requests {
+				if request.ctx.Err() != nil {
+					delete(requests, id)
+				}
+			}
+
+		// ping
+		case <-pingTicker.C:
+			bytes, err := lib.Marshal(&lib.Ping{Payload: []byte("天王盖地虎")})
+			if err != nil {
+				return
+			}
+
+			if err := cn.send(&lib.Packet{
+				Kind: lib.PackKind_PING,
+				Data: bytes,
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// 服务器即将关闭，retryAfter 之后重新连接
+type goaway_t struct {
+	retryAfter time.Duration
+}
+
+func (g *goaway_t) Error() string {
+	return fmt.Sprintf("server going away, retry after %v", g.retryAfter)
+}
+
+// 处理从服务器接收的 packet，返回 error 时断开连接
+func (c *Client) handlePack(pack *lib.Packet, resChan chan<- *lib.Packet) (err error) {
+	switch pack.Kind {
+
+	// pong
+	case lib.PackKind_PONG:
+
+	// 当前连接用户收到服务器 push
+	case lib.PackKind_PUSH:
+		push := &lib.Push{}
+		if err = lib.Unmarshal(pack.Data, push); err != nil {
+			return
+		}
+		select {
+		case c.pushes <- push:
+		case <-c.closed:
+		}
+
+	// 当前连接的登录用户收到新消息，也可能是自己从其他设备发送的消息
+	case lib.PackKind_MSG:
+		msg := &lib.Msg{}
+		if err = lib.Unmarshal(pack.Data, msg); err != nil {
+			return
+		}
+		select {
+		case c.msgs <- msg:
+		case <-c.closed:
+		}
+
+	// 服务器返回错误后会断开连接
+	case lib.PackKind_ERR:
+		errRes := &lib.ErrRes{}
+		if err = lib.Unmarshal(pack.Data, errRes); err != nil {
+			return
+		}
+		// 当前登录已被撤销，重新连接后不再自动登录
+		if errRes.Code == lib.Err_Token_Revoked.Val() {
+			c.setToken(nil)
+		}
+		err = &ServerError{errRes.Code}
+		c.emitErr(err)
+
+	// 服务器即将关闭，断开连接并稍后重新连接
+	case lib.PackKind_GOAWAY:
+		goAway := &lib.GoAway{}
+		if err = lib.Unmarshal(pack.Data, goAway); err != nil {
+			return
+		}
+		err = &goaway_t{time.Duration(goAway.RetryAfter) * time.Second}
+
+	// 收到同步请求的响应，通过 resChan 发到 sendTo 协程
+	case lib.PackKind_RES:
+		resChan <- pack
+
+	// 忽略不支持的 packet 类型，新版本服务器可以增加 packet 类型
+	default:
+	}
+	return
+}
+
+// 从服务器接收 packet 并进行处理
+func (c *Client) recvFrom(cn *conn_t, resChan chan<- *lib.Packet, goAwayChan chan<- time.Duration) {
+	// 协程退出前关闭 channel
+	defer close(resChan)
+
+	// 循环解析消息，每读取一个 packet 执行一次处理逻辑
+	for {
+		// 每隔 PING_INTERVAL 会发送 ping，如果超过 PING_INTERVAL+PONG_TIMEOUT 没有收到任何 packet (包括 pong)，则认为连接已断开，退出后会重新连接
+		cn.SetReadDeadline(time.Now().Add(PING_INTERVAL + PONG_TIMEOUT))
+
+		pack := &lib.Packet{}
+		if err := cn.r.ReadPack(pack); err != nil {
+			return
+		}
+
+		// 执行 packet 处理逻辑
+		if err := c.handlePack(pack, resChan); err != nil {
+			var goAway *goaway_t
+			if errors.As(err, &goAway) {
+				goAwayChan <- goAway.retryAfter
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 转换请求类型
+func requestToKind(m proto.Message) (kind lib.PackKind, err error) {
+	switch m.(type) {
+	case *lib.Signup:
+		kind = lib.PackKind_SIGNUP
+	case *lib.Signin:
+		kind = lib.PackKind_SIGNIN
+	case *lib.Token:
+		kind = lib.PackKind_TOKEN
+	case *lib.Signout:
+		kind = lib.PackKind_SIGNOUT
+	case *lib.Users:
+		kind = lib.PackKind_USERS
+	case *lib.GroupCreate:
+		kind = lib.PackKind_GROUP_CREATE
+	case *lib.GroupInvite:
+		kind = lib.PackKind_GROUP_INVITE
+	case *lib.GroupKick:
+		kind = lib.PackKind_GROUP_KICK
+	case *lib.GroupLeave:
+		kind = lib.PackKind_GROUP_LEAVE
+	case *lib.Groups:
+		kind = lib.PackKind_GROUPS
+	case *lib.History:
+		kind = lib.PackKind_HISTORY
+	case *lib.Msg:
+		kind = lib.PackKind_MSG
+	case *lib.Receipt:
+		kind = lib.PackKind_RECEIPT
+	case *lib.PublishKey:
+		kind = lib.PackKind_PUBLISH_KEY
+	case *lib.GetKey:
+		kind = lib.PackKind_GET_KEY
+	case *lib.Sessions:
+		kind = lib.PackKind_SESSIONS
+	case *lib.Revoke:
+		kind = lib.PackKind_REVOKE
+	default:
+		err = errors.New("invalid kind of packet")
+	}
+	return
+}
+
+// 封装服务器请求，会向服务器发送 packet
+type request_t struct {
+	ctx  context.Context
+	pack *lib.Packet
+	// 同步请求通过该 channel 返回响应，连接断开时返回 nil
+	c chan *lib.Packet
+}
+
+// 创建服务器请求对象
+func newRequest(ctx context.Context, pack *lib.Packet, sync bool) (request *request_t) {
+	request = &request_t{ctx: ctx, pack: pack}
+	// 同步请求发送后，可通过 request.c channel 获取响应
+	if sync {
+		request.c = make(chan *lib.Packet, 1)
+	}
+	return
+}
+
+// 判断当前请求是否为同步请求
+func (request *request_t) sync() bool {
+	return request.c != nil
+}
+
+// 实现 post 接口
+type poster_t struct {
+	c *Client
+}
+
+// Handle implements lib.Post
+func (p *poster_t) Handle(req, res proto.Message) error {
+	return p.c.Do(context.Background(), req, res)
+}
+
+// Send implements lib.Post
+func (p *poster_t) Send(req proto.Message) error {
+	return p.c.Notify(req)
+}
+
+// Close implements lib.Post
+func (p *poster_t) Close() {
+	p.c.Close()
+}
+
+var _ lib.Post = (*poster_t)(nil)
@@ -1,15 +1,34 @@
 package lib
 
 import (
+	"bufio"
 	b "bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
 
 	"google.golang.org/protobuf/proto"
 )
 
 const PackLenField = 4
 
+// 默认最大帧长度 (不包括长度字段)
+const DEFAULT_MAX_FRAME_SIZE uint32 = 1 << 20
+
+// 超过该大小的读写缓冲区用完后不再保留，避免偶尔出现的大帧长期占用内存
+const maxRetainedBuffer = 64 << 10
+
+// 帧长度超过限制
+type FrameSizeError struct {
+	Size uint32
+	Max  uint32
+}
+
+func (e *FrameSizeError) Error() string {
+	return fmt.Sprintf("frame size %d exceeds limit %d", e.Size, e.Max)
+}
+
 func Uint32ToBytes(n uint32) (bytes []byte) {
 	bytes = make([]byte, PackLenField)
 	binary.BigEndian.PutUint32(bytes, n)
@@ -20,22 +39,117 @@ func BytesToUint32(bytes []byte) uint32 {
 	return binary.BigEndian.Uint32(bytes)
 }
 
+// 返回按照 packet 开头 length 分割字节流的 SplitFunc。读到 length 后立即检查是否超过 max，不会等待读取完整的帧
+//
+// 返回的 token 引用 bufio.Scanner 的缓冲区，下次调用 Scan 之前有效。bufio.Scanner 默认最多缓冲 64KB，max 更大时需要调用 scanner.Buffer 设置
+func NewSplitFunc(max uint32) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) >= PackLenField {
+			length := BytesToUint32(data[:PackLenField])
+			if length > max {
+				return 0, nil, &FrameSizeError{length, max}
+			}
+
+			if uint32(len(data)-PackLenField) >= length {
+				advance = PackLenField + int(length)
+				token = data[PackLenField:advance]
+			}
+		}
+
+		if atEOF && len(data[advance:]) > 0 {
+			err = errors.New("遇到 EOF，但数据不完整，未能解析出数据包！")
+		}
+
+		return
+	}
+}
+
+// 最大帧长度为 DEFAULT_MAX_FRAME_SIZE 的 SplitFunc
 func SplitFunc(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if len(data) >= PackLenField {
-		length := BytesToUint32(data[:PackLenField])
+	return defaultSplitFunc(data, atEOF)
+}
+
+var defaultSplitFunc = NewSplitFunc(DEFAULT_MAX_FRAME_SIZE)
+
+// 从字节流中逐帧读取 packet，复用读缓冲区
+type FrameReader struct {
+	r   *bufio.Reader
+	max uint32
+	hdr [PackLenField]byte
+	buf []byte
+}
+
+func NewFrameReader(r io.Reader, max uint32) *FrameReader {
+	return &FrameReader{r: bufio.NewReader(r), max: max}
+}
 
-		if uint32(len(data)-PackLenField) >= length {
-			advance = int(PackLenField + length)
-			token = make([]byte, length)
-			copy(token, data[PackLenField:advance])
+// 读取下一帧，返回的 slice 在下次调用 Next 之前有效。帧长度超过限制时返回 *FrameSizeError，此时不会读取帧内容，字节流无法继续解析
+func (f *FrameReader) Next() ([]byte, error) {
+	if _, err := io.ReadFull(f.r, f.hdr[:]); err != nil {
+		return nil, err
+	}
+
+	length := BytesToUint32(f.hdr[:])
+	if length > f.max {
+		return nil, &FrameSizeError{length, f.max}
+	}
+
+	buf := f.buf
+	if cap(buf) < int(length) {
+		buf = make([]byte, length)
+	}
+	buf = buf[:length]
+	if cap(buf) <= maxRetainedBuffer {
+		f.buf = buf
+	}
+
+	if _, err := io.ReadFull(f.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
 		}
+		return nil, err
 	}
+	return buf, nil
+}
 
-	if atEOF && len(data[advance:]) > 0 {
-		err = errors.New("遇到 EOF，但数据不完整，未能解析出数据包！")
+// 读取并解析下一个 packet
+func (f *FrameReader) ReadPack(pack *Packet) error {
+	frame, err := f.Next()
+	if err != nil {
+		return err
 	}
+	return Unmarshal(frame, pack)
+}
 
-	return
+// 逐帧写入 packet，复用写缓冲区，长度字段和 packet 通过一次 Write 写入
+type FrameWriter struct {
+	w   io.Writer
+	max uint32
+	buf []byte
+}
+
+func NewFrameWriter(w io.Writer, max uint32) *FrameWriter {
+	return &FrameWriter{w: w, max: max}
+}
+
+// 序列化并写入 packet。帧长度超过限制时返回 *FrameSizeError，不会写入任何数据
+func (f *FrameWriter) WritePack(pack proto.Message) error {
+	buf, err := proto.MarshalOptions{}.MarshalAppend(append(f.buf[:0], make([]byte, PackLenField)...), pack)
+	if err != nil {
+		return err
+	}
+	if cap(buf) <= maxRetainedBuffer {
+		f.buf = buf
+	}
+
+	size := uint32(len(buf) - PackLenField)
+	if size > f.max {
+		return &FrameSizeError{size, f.max}
+	}
+	binary.BigEndian.PutUint32(buf, size)
+
+	_, err = f.w.Write(buf)
+	return err
 }
 
 func MarshalPack(pack proto.Message) (bytes []byte, err error) {
@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 测试使用的最大帧长度
+const fuzzMaxFrameSize = 1 << 10
+
+// 用 SplitFunc 分割 data，返回分割出的所有帧
+func splitFrames(data []byte, max uint32) (frames [][]byte, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64), int(max)+PackLenField)
+	scanner.Split(NewSplitFunc(max))
+	for scanner.Scan() {
+		frames = append(frames, append([]byte{}, scanner.Bytes()...))
+	}
+	return frames, scanner.Err()
+}
+
+// 种子语料: 合法的 packet 流、不完整的帧、超长的 length
+func fuzzSeeds(f *testing.F) {
+	var stream bytes.Buffer
+	w := NewFrameWriter(&stream, fuzzMaxFrameSize)
+	for _, pack := range []*Packet{
+		{},
+		{Kind: PackKind_PING, Id: 1, Data: []byte("天王盖地虎")},
+		{Kind: PackKind_MSG, Id: 1 << 40, Data: bytes.Repeat([]byte{0xff}, 100)},
+	} {
+		if err := w.WritePack(pack); err != nil {
+			f.Fatal(err)
+		}
+	}
+
+	f.Add(stream.Bytes())
+	f.Add(stream.Bytes()[:stream.Len()-1])
+	f.Add([]byte{0, 0, 0})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 1, 2, 3})
+	f.Add([]byte{0, 0, 0, 2, 0x08, 0x80})
+}
+
+// SplitFunc 和 FrameReader 必须分割出相同的帧，帧长度不能超过限制，能够解析的 packet 重新序列化后内容不变
+func FuzzSplitFunc(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frames, splitErr := splitFrames(data, fuzzMaxFrameSize)
+
+		r := NewFrameReader(bytes.NewReader(data), fuzzMaxFrameSize)
+		for i := 0; ; i++ {
+			frame, err := r.Next()
+			if err != nil {
+				if i != len(frames) {
+					t.Fatalf("FrameReader read %d frames, SplitFunc %d", i, len(frames))
+				}
+				// 超长帧两者都必须返回 FrameSizeError
+				var sizeErr *FrameSizeError
+				if errors.As(err, &sizeErr) != errors.As(splitErr, &sizeErr) {
+					t.Fatalf("FrameReader error %v, SplitFunc error %v", err, splitErr)
+				}
+				break
+			}
+			if i >= len(frames) || !bytes.Equal(frame, frames[i]) {
+				t.Fatalf("frame %d differs", i)
+			}
+		}
+
+		for _, frame := range frames {
+			if len(frame) > fuzzMaxFrameSize {
+				t.Fatalf("frame size %d exceeds limit", len(frame))
+			}
+
+			pack := &Packet{}
+			if Unmarshal(frame, pack) != nil {
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := NewFrameWriter(&buf, fuzzMaxFrameSize+PackLenField).WritePack(pack); err != nil {
+				t.Fatal(err)
+			}
+			again, err := splitFrames(buf.Bytes(), fuzzMaxFrameSize+PackLenField)
+			if err != nil || len(again) != 1 {
+				t.Fatalf("re-split: %d frames, %v", len(again), err)
+			}
+			got := &Packet{}
+			if err := Unmarshal(again[0], got); err != nil || !proto.Equal(pack, got) {
+				t.Fatalf("round trip: %v, %v != %v", err, got, pack)
+			}
+		}
+	})
+}
+
+// 超过最大长度的 packet 不会写入任何数据
+func TestFrameWriterLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFrameWriter(&buf, fuzzMaxFrameSize)
+
+	err := w.WritePack(&Packet{Data: make([]byte, fuzzMaxFrameSize)})
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Max != fuzzMaxFrameSize {
+		t.Fatalf("expected FrameSizeError, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("%d bytes written", buf.Len())
+	}
+
+	// 之后仍然可以写入正常的 packet
+	if err := w.WritePack(&Packet{Kind: PackKind_PING}); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := splitFrames(buf.Bytes(), fuzzMaxFrameSize)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("%d frames, %v", len(frames), err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 	"io"
 )
 
@@ -40,6 +41,9 @@ func Encrypt(plaintext []byte, gcm cipher.AEAD) []byte {
 }
 
 func Decrypt(ciphertext []byte, gcm cipher.AEAD) ([]byte, error) {
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
 	// 首先得到加密时使用的 nonce
 	nonce := ciphertext[:gcm.NonceSize()]
 	// 传入 nonce 并进行数据解密
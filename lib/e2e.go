@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const e2eInfo = "GoChat e2e v1"
+
+const backupInfo = "GoChat identity backup v1"
+
+// 生成 X25519 身份密钥对
+func NewIdentityKey() (priv, pub *[32]byte, err error) {
+	priv = &[32]byte{}
+	if _, err = io.ReadFull(rand.Reader, priv[:]); err != nil {
+		return
+	}
+	pub, err = PublicKey(priv)
+	return
+}
+
+// 根据 X25519 私钥计算公钥
+func PublicKey(priv *[32]byte) (pub *[32]byte, err error) {
+	p, err := curve25519.X25519(priv[:], curve25519.Basepoint)
+	if err != nil {
+		return
+	}
+	pub = &[32]byte{}
+	copy(pub[:], p)
+	return
+}
+
+// 根据自己的私钥与对方的公钥协商出会话密钥，双方计算结果相同
+func SharedKey(priv *[32]byte, peerPub []byte) (key *[32]byte, err error) {
+	secret, err := curve25519.X25519(priv[:], peerPub)
+	if err != nil {
+		return
+	}
+	key = &[32]byte{}
+	_, err = io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(e2eInfo)), key[:])
+	return
+}
+
+// 使用会话密钥加密消息内容，ad 为附加数据，解密时必须提供相同的 ad
+func Seal(plaintext []byte, key *[32]byte, ad []byte) ([]byte, error) {
+	gcm, err := NewGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := randNonce(gcm.NonceSize())
+	return gcm.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+// 使用会话密钥解密消息内容，密文被篡改或 ad 不同时返回 error
+func Open(ciphertext []byte, key *[32]byte, ad []byte) ([]byte, error) {
+	gcm, err := NewGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], ad)
+}
+
+// from 发送给 to 的单聊加密消息的附加数据 (from|to)，密文无法在其他会话中重放。from 带有长度前缀，避免不同的用户名拼接后相同
+func MsgAD(from, to string) []byte {
+	ad := binary.AppendUvarint(nil, uint64(len(from)))
+	ad = append(ad, from...)
+	ad = append(ad, '|')
+	return append(ad, to...)
+}
+
+// 身份私钥备份中随机盐的长度
+const BACKUP_SALT_SIZE = 16
+
+// 使用备份口令加密 username 的身份私钥，备份格式: 随机盐 | 密文
+//
+// 备份口令与登录密码不同，不会发送给服务器。服务器即使通过密码摘要猜出登录密码，也无法解密备份
+func SealBackup(priv *[32]byte, username, passphrase string) ([]byte, error) {
+	salt := make([]byte, BACKUP_SALT_SIZE)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	sealed, err := Seal(priv[:], backupKey(passphrase, salt), []byte(backupInfo+username))
+	if err != nil {
+		return nil, err
+	}
+	return append(salt, sealed...), nil
+}
+
+// 使用备份口令解密 username 的身份私钥备份，口令错误或备份被篡改时返回 error
+func OpenBackup(backup []byte, username, passphrase string) (*[32]byte, error) {
+	if len(backup) < BACKUP_SALT_SIZE {
+		return nil, errors.New("backup too short")
+	}
+	b, err := Open(backup[BACKUP_SALT_SIZE:], backupKey(passphrase, backup[:BACKUP_SALT_SIZE]), []byte(backupInfo+username))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 32 {
+		return nil, errors.New("invalid identity key backup")
+	}
+	return (*[32]byte)(b), nil
+}
+
+// 使用 Argon2id 从备份口令和随机盐派生加密备份的密钥
+func backupKey(passphrase string, salt []byte) *[32]byte {
+	key := &[32]byte{}
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32))
+	return key
+}
@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// 协商 alice 和 bob 的会话密钥，双方计算结果相同
+func sharedKeys(t *testing.T) (alice, bob *[32]byte) {
+	t.Helper()
+	alicePriv, alicePub, err := NewIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPriv, bobPub, err := NewIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice, err = SharedKey(alicePriv, bobPub[:]); err != nil {
+		t.Fatal(err)
+	}
+	if bob, err = SharedKey(bobPriv, alicePub[:]); err != nil {
+		t.Fatal(err)
+	}
+	if *alice != *bob {
+		t.Fatal("shared keys differ")
+	}
+	return
+}
+
+// 加密后对方可以解密，相同明文每次加密结果不同
+func TestSealOpen(t *testing.T) {
+	alice, bob := sharedKeys(t)
+	plaintext := []byte("天王盖地虎")
+
+	sealed, err := Seal(plaintext, alice, MsgAD("alice", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := Open(sealed, bob, MsgAD("alice", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("opened = %q, want %q", opened, plaintext)
+	}
+
+	again, err := Seal(plaintext, alice, MsgAD("alice", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("sealing twice should use different nonces")
+	}
+}
+
+// 篡改密文、使用其他密钥或者在其他会话中重放时无法解密
+func TestOpenTampered(t *testing.T) {
+	key, _ := sharedKeys(t)
+	other, _ := sharedKeys(t)
+	ad := MsgAD("alice", "bob")
+	sealed, err := Seal([]byte("hello"), key, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range sealed {
+		tampered := append([]byte{}, sealed...)
+		tampered[i] ^= 1
+		if _, err := Open(tampered, key, ad); err == nil {
+			t.Fatalf("tampered byte %d should fail", i)
+		}
+	}
+	for name, c := range map[string]struct {
+		ciphertext []byte
+		key        *[32]byte
+		ad         []byte
+	}{
+		"wrong key":      {sealed, other, ad},
+		"reversed":       {sealed, key, MsgAD("bob", "alice")},
+		"other receiver": {sealed, key, MsgAD("alice", "carol")},
+		"ambiguous":      {sealed, key, MsgAD("alice|", "bob")},
+		"no ad":          {sealed, key, nil},
+		"too short":      {sealed[:4], key, ad},
+	} {
+		if _, err := Open(c.ciphertext, c.key, c.ad); err == nil {
+			t.Errorf("%s: should fail", name)
+		}
+	}
+	if bytes.Equal(MsgAD("ab", "c"), MsgAD("a", "bc")) {
+		t.Error("MsgAD should not be ambiguous")
+	}
+}
+
+// 备份只能使用备份口令解密，每次备份使用不同的随机盐
+func TestBackup(t *testing.T) {
+	priv, _, err := NewIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := SealBackup(priv, "alice", "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenBackup(backup, "alice", "correct horse battery staple")
+	if err != nil || *opened != *priv {
+		t.Fatalf("OpenBackup = %v, %v", opened, err)
+	}
+
+	again, err := SealBackup(priv, "alice", "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again[:BACKUP_SALT_SIZE], backup[:BACKUP_SALT_SIZE]) {
+		t.Error("backups should use different salts")
+	}
+	if _, err := OpenBackup(backup, "bob", "correct horse battery staple"); err == nil {
+		t.Error("backup should be bound to the username")
+	}
+	if _, err := OpenBackup(backup[:BACKUP_SALT_SIZE-1], "alice", "correct horse battery staple"); err == nil {
+		t.Error("truncated backup should fail")
+	}
+}
+
+// 服务器保存了用户名、无盐的密码摘要和备份。即使从摘要中猜出登录密码，也无法用服务器掌握的信息解密备份
+func TestBackupServerView(t *testing.T) {
+	const password = "hello123"
+	priv, _, err := NewIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := SealBackup(priv, "alice", "backup passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passhash := sha256.Sum256([]byte(password))
+
+	// 服务器对密码摘要做字典攻击
+	var cracked string
+	for _, guess := range []string{"123456", "password", "hello123"} {
+		if sha256.Sum256([]byte(guess)) == passhash {
+			cracked = guess
+		}
+	}
+	if cracked != password {
+		t.Fatal("dictionary attack on passhash should succeed")
+	}
+
+	for _, guess := range []string{"", "alice", cracked, hex.EncodeToString(passhash[:]), string(passhash[:])} {
+		if _, err := OpenBackup(backup, "alice", guess); err == nil {
+			t.Errorf("backup opened with %q", guess)
+		}
+	}
+}
@@ -18,4 +18,20 @@ const (
 	Err_Bcrypt_Compare
 	Err_Forbidden
 	Err_Get_Users
+	Err_Create_Group
+	Err_Group_Not_Exist
+	Err_Not_Group_Member
+	Err_Not_Group_Owner
+	Err_Update_Group
+	Err_Get_Groups
+	Err_Get_History
+	Err_New_Msg
+	Err_Invalid_Key
+	Err_Key_Not_Exist
+	Err_Token_Revoked
+	Err_Session_Not_Exist
+	Err_Get_Sessions
+	Err_Version_Unsupported
+	Err_Unknown_Kind
+	Err_Frame_Too_Large
 )
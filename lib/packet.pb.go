@@ -39,6 +39,28 @@ const (
 	PackKind_TOKEN   PackKind = 8
 	PackKind_SIGNOUT PackKind = 9
 	PackKind_USERS   PackKind = 10
+	// Group
+	PackKind_GROUP_CREATE PackKind = 11
+	PackKind_GROUP_INVITE PackKind = 12
+	PackKind_GROUP_KICK   PackKind = 13
+	PackKind_GROUP_LEAVE  PackKind = 14
+	PackKind_GROUPS       PackKind = 15
+	// History
+	PackKind_HISTORY PackKind = 16
+	// Receipt
+	PackKind_RECEIPT PackKind = 17
+	// Key directory
+	PackKind_PUBLISH_KEY PackKind = 18
+	PackKind_GET_KEY     PackKind = 19
+	// Session
+	PackKind_SESSIONS PackKind = 20
+	PackKind_REVOKE   PackKind = 21
+	// Server
+	PackKind_GOAWAY PackKind = 22
+	// Handshake
+	PackKind_HELLO PackKind = 23
+	// Cluster
+	PackKind_CLUSTER PackKind = 24
 )
 
 // Enum value maps for PackKind.
@@ -55,19 +77,47 @@ var (
 		8:  "TOKEN",
 		9:  "SIGNOUT",
 		10: "USERS",
+		11: "GROUP_CREATE",
+		12: "GROUP_INVITE",
+		13: "GROUP_KICK",
+		14: "GROUP_LEAVE",
+		15: "GROUPS",
+		16: "HISTORY",
+		17: "RECEIPT",
+		18: "PUBLISH_KEY",
+		19: "GET_KEY",
+		20: "SESSIONS",
+		21: "REVOKE",
+		22: "GOAWAY",
+		23: "HELLO",
+		24: "CLUSTER",
 	}
 	PackKind_value = map[string]int32{
-		"PONG":    0,
-		"ERR":     1,
-		"RES":     2,
-		"PUSH":    3,
-		"MSG":     4,
-		"PING":    5,
-		"SIGNUP":  6,
-		"SIGNIN":  7,
-		"TOKEN":   8,
-		"SIGNOUT": 9,
-		"USERS":   10,
+		"PONG":         0,
+		"ERR":          1,
+		"RES":          2,
+		"PUSH":         3,
+		"MSG":          4,
+		"PING":         5,
+		"SIGNUP":       6,
+		"SIGNIN":       7,
+		"TOKEN":        8,
+		"SIGNOUT":      9,
+		"USERS":        10,
+		"GROUP_CREATE": 11,
+		"GROUP_INVITE": 12,
+		"GROUP_KICK":   13,
+		"GROUP_LEAVE":  14,
+		"GROUPS":       15,
+		"HISTORY":      16,
+		"RECEIPT":      17,
+		"PUBLISH_KEY":  18,
+		"GET_KEY":      19,
+		"SESSIONS":     20,
+		"REVOKE":       21,
+		"GOAWAY":       22,
+		"HELLO":        23,
+		"CLUSTER":      24,
 	}
 )
 
@@ -101,16 +151,19 @@ func (PackKind) EnumDescriptor() ([]byte, []int) {
 type MsgKind int32
 
 const (
-	MsgKind_TEXT MsgKind = 0
+	MsgKind_TEXT   MsgKind = 0
+	MsgKind_SEALED MsgKind = 1
 )
 
 // Enum value maps for MsgKind.
 var (
 	MsgKind_name = map[int32]string{
 		0: "TEXT",
+		1: "SEALED",
 	}
 	MsgKind_value = map[string]int32{
-		"TEXT": 0,
+		"TEXT":   0,
+		"SEALED": 1,
 	}
 )
 
@@ -141,19 +194,71 @@ func (MsgKind) EnumDescriptor() ([]byte, []int) {
 	return file_packet_proto_rawDescGZIP(), []int{1}
 }
 
+type MsgStatus int32
+
+const (
+	MsgStatus_SENT      MsgStatus = 0
+	MsgStatus_DELIVERED MsgStatus = 1
+	MsgStatus_READ      MsgStatus = 2
+)
+
+// Enum value maps for MsgStatus.
+var (
+	MsgStatus_name = map[int32]string{
+		0: "SENT",
+		1: "DELIVERED",
+		2: "READ",
+	}
+	MsgStatus_value = map[string]int32{
+		"SENT":      0,
+		"DELIVERED": 1,
+		"READ":      2,
+	}
+)
+
+func (x MsgStatus) Enum() *MsgStatus {
+	p := new(MsgStatus)
+	*p = x
+	return p
+}
+
+func (x MsgStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MsgStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_packet_proto_enumTypes[2].Descriptor()
+}
+
+func (MsgStatus) Type() protoreflect.EnumType {
+	return &file_packet_proto_enumTypes[2]
+}
+
+func (x MsgStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MsgStatus.Descriptor instead.
+func (MsgStatus) EnumDescriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{2}
+}
+
 type PushKind int32
 
 const (
-	PushKind_ONLINE PushKind = 0
+	PushKind_ONLINE      PushKind = 0
+	PushKind_MSG_RECEIPT PushKind = 1
 )
 
 // Enum value maps for PushKind.
 var (
 	PushKind_name = map[int32]string{
 		0: "ONLINE",
+		1: "MSG_RECEIPT",
 	}
 	PushKind_value = map[string]int32{
-		"ONLINE": 0,
+		"ONLINE":      0,
+		"MSG_RECEIPT": 1,
 	}
 )
 
@@ -168,11 +273,11 @@ func (x PushKind) String() string {
 }
 
 func (PushKind) Descriptor() protoreflect.EnumDescriptor {
-	return file_packet_proto_enumTypes[2].Descriptor()
+	return file_packet_proto_enumTypes[3].Descriptor()
 }
 
 func (PushKind) Type() protoreflect.EnumType {
-	return &file_packet_proto_enumTypes[2]
+	return &file_packet_proto_enumTypes[3]
 }
 
 func (x PushKind) Number() protoreflect.EnumNumber {
@@ -181,7 +286,7 @@ func (x PushKind) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use PushKind.Descriptor instead.
 func (PushKind) EnumDescriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{2}
+	return file_packet_proto_rawDescGZIP(), []int{3}
 }
 
 type OnlineKind int32
@@ -214,11 +319,11 @@ func (x OnlineKind) String() string {
 }
 
 func (OnlineKind) Descriptor() protoreflect.EnumDescriptor {
-	return file_packet_proto_enumTypes[3].Descriptor()
+	return file_packet_proto_enumTypes[4].Descriptor()
 }
 
 func (OnlineKind) Type() protoreflect.EnumType {
-	return &file_packet_proto_enumTypes[3]
+	return &file_packet_proto_enumTypes[4]
 }
 
 func (x OnlineKind) Number() protoreflect.EnumNumber {
@@ -227,7 +332,77 @@ func (x OnlineKind) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use OnlineKind.Descriptor instead.
 func (OnlineKind) EnumDescriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{3}
+	return file_packet_proto_rawDescGZIP(), []int{4}
+}
+
+type ClusterKind int32
+
+const (
+	ClusterKind_CLUSTER_HELLO     ClusterKind = 0
+	ClusterKind_CLUSTER_PING      ClusterKind = 1
+	ClusterKind_CLUSTER_ONLINE    ClusterKind = 2
+	ClusterKind_CLUSTER_OFFLINE   ClusterKind = 3
+	ClusterKind_CLUSTER_MSG       ClusterKind = 4
+	ClusterKind_CLUSTER_SYNC      ClusterKind = 5
+	ClusterKind_CLUSTER_PUSH      ClusterKind = 6
+	ClusterKind_CLUSTER_BROADCAST ClusterKind = 7
+	ClusterKind_CLUSTER_REVOKE    ClusterKind = 8
+	ClusterKind_CLUSTER_CHALLENGE ClusterKind = 9
+)
+
+// Enum value maps for ClusterKind.
+var (
+	ClusterKind_name = map[int32]string{
+		0: "CLUSTER_HELLO",
+		1: "CLUSTER_PING",
+		2: "CLUSTER_ONLINE",
+		3: "CLUSTER_OFFLINE",
+		4: "CLUSTER_MSG",
+		5: "CLUSTER_SYNC",
+		6: "CLUSTER_PUSH",
+		7: "CLUSTER_BROADCAST",
+		8: "CLUSTER_REVOKE",
+		9: "CLUSTER_CHALLENGE",
+	}
+	ClusterKind_value = map[string]int32{
+		"CLUSTER_HELLO":     0,
+		"CLUSTER_PING":      1,
+		"CLUSTER_ONLINE":    2,
+		"CLUSTER_OFFLINE":   3,
+		"CLUSTER_MSG":       4,
+		"CLUSTER_SYNC":      5,
+		"CLUSTER_PUSH":      6,
+		"CLUSTER_BROADCAST": 7,
+		"CLUSTER_REVOKE":    8,
+		"CLUSTER_CHALLENGE": 9,
+	}
+)
+
+func (x ClusterKind) Enum() *ClusterKind {
+	p := new(ClusterKind)
+	*p = x
+	return p
+}
+
+func (x ClusterKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ClusterKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_packet_proto_enumTypes[5].Descriptor()
+}
+
+func (ClusterKind) Type() protoreflect.EnumType {
+	return &file_packet_proto_enumTypes[5]
+}
+
+func (x ClusterKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ClusterKind.Descriptor instead.
+func (ClusterKind) EnumDescriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{5}
 }
 
 type Packet struct {
@@ -293,6 +468,158 @@ func (x *Packet) GetData() []byte {
 	return nil
 }
 
+// 建立连接后客户端发送的第一个 packet，用于协商协议版本
+type Hello struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version  uint32   `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Features []string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
+	Build    string   `protobuf:"bytes,3,opt,name=build,proto3" json:"build,omitempty"`
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Hello) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *Hello) GetBuild() string {
+	if x != nil {
+		return x.Build
+	}
+	return ""
+}
+
+// min_version 为服务器支持的最低协议版本，code 为 Err_Version_Unsupported 时服务器会断开连接。max_frame_size 为服务器收发 packet 的最大长度
+type HelloRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code         int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Version      uint32   `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	MinVersion   uint32   `protobuf:"varint,3,opt,name=min_version,json=minVersion,proto3" json:"min_version,omitempty"`
+	Features     []string `protobuf:"bytes,4,rep,name=features,proto3" json:"features,omitempty"`
+	Build        string   `protobuf:"bytes,5,opt,name=build,proto3" json:"build,omitempty"`
+	MaxFrameSize uint32   `protobuf:"varint,6,opt,name=max_frame_size,json=maxFrameSize,proto3" json:"max_frame_size,omitempty"`
+}
+
+func (x *HelloRes) Reset() {
+	*x = HelloRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HelloRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloRes) ProtoMessage() {}
+
+func (x *HelloRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloRes.ProtoReflect.Descriptor instead.
+func (*HelloRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HelloRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *HelloRes) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HelloRes) GetMinVersion() uint32 {
+	if x != nil {
+		return x.MinVersion
+	}
+	return 0
+}
+
+func (x *HelloRes) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *HelloRes) GetBuild() string {
+	if x != nil {
+		return x.Build
+	}
+	return ""
+}
+
+func (x *HelloRes) GetMaxFrameSize() uint32 {
+	if x != nil {
+		return x.MaxFrameSize
+	}
+	return 0
+}
+
 type Ping struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -304,7 +631,7 @@ type Ping struct {
 func (x *Ping) Reset() {
 	*x = Ping{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[1]
+		mi := &file_packet_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -317,7 +644,7 @@ func (x *Ping) String() string {
 func (*Ping) ProtoMessage() {}
 
 func (x *Ping) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[1]
+	mi := &file_packet_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -330,7 +657,7 @@ func (x *Ping) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Ping.ProtoReflect.Descriptor instead.
 func (*Ping) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{1}
+	return file_packet_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Ping) GetPayload() []byte {
@@ -351,7 +678,7 @@ type Pong struct {
 func (x *Pong) Reset() {
 	*x = Pong{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[2]
+		mi := &file_packet_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -364,7 +691,7 @@ func (x *Pong) String() string {
 func (*Pong) ProtoMessage() {}
 
 func (x *Pong) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[2]
+	mi := &file_packet_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +704,7 @@ func (x *Pong) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Pong.ProtoReflect.Descriptor instead.
 func (*Pong) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{2}
+	return file_packet_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *Pong) GetPayload() []byte {
@@ -399,7 +726,7 @@ type Auth struct {
 func (x *Auth) Reset() {
 	*x = Auth{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[3]
+		mi := &file_packet_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -412,7 +739,7 @@ func (x *Auth) String() string {
 func (*Auth) ProtoMessage() {}
 
 func (x *Auth) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[3]
+	mi := &file_packet_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -425,7 +752,7 @@ func (x *Auth) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Auth.ProtoReflect.Descriptor instead.
 func (*Auth) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{3}
+	return file_packet_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Auth) GetUsername() string {
@@ -442,18 +769,20 @@ func (x *Auth) GetPasshash() []byte {
 	return nil
 }
 
+// bot 为 true 时注册为机器人帐号，用户列表中会标记机器人
 type Signup struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Auth *Auth `protobuf:"bytes,1,opt,name=auth,proto3" json:"auth,omitempty"`
+	Bot  bool  `protobuf:"varint,2,opt,name=bot,proto3" json:"bot,omitempty"`
 }
 
 func (x *Signup) Reset() {
 	*x = Signup{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[4]
+		mi := &file_packet_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -466,7 +795,7 @@ func (x *Signup) String() string {
 func (*Signup) ProtoMessage() {}
 
 func (x *Signup) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[4]
+	mi := &file_packet_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -479,7 +808,7 @@ func (x *Signup) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Signup.ProtoReflect.Descriptor instead.
 func (*Signup) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{4}
+	return file_packet_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Signup) GetAuth() *Auth {
@@ -489,6 +818,13 @@ func (x *Signup) GetAuth() *Auth {
 	return nil
 }
 
+func (x *Signup) GetBot() bool {
+	if x != nil {
+		return x.Bot
+	}
+	return false
+}
+
 type Signin struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -500,7 +836,7 @@ type Signin struct {
 func (x *Signin) Reset() {
 	*x = Signin{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[5]
+		mi := &file_packet_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -513,7 +849,7 @@ func (x *Signin) String() string {
 func (*Signin) ProtoMessage() {}
 
 func (x *Signin) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[5]
+	mi := &file_packet_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -526,7 +862,7 @@ func (x *Signin) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Signin.ProtoReflect.Descriptor instead.
 func (*Signin) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{5}
+	return file_packet_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Signin) GetAuth() *Auth {
@@ -547,7 +883,7 @@ type Token struct {
 func (x *Token) Reset() {
 	*x = Token{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[6]
+		mi := &file_packet_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -560,7 +896,7 @@ func (x *Token) String() string {
 func (*Token) ProtoMessage() {}
 
 func (x *Token) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[6]
+	mi := &file_packet_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -573,7 +909,7 @@ func (x *Token) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Token.ProtoReflect.Descriptor instead.
 func (*Token) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{6}
+	return file_packet_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Token) GetToken() []byte {
@@ -597,7 +933,7 @@ type TokenRes struct {
 func (x *TokenRes) Reset() {
 	*x = TokenRes{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[7]
+		mi := &file_packet_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -610,7 +946,7 @@ func (x *TokenRes) String() string {
 func (*TokenRes) ProtoMessage() {}
 
 func (x *TokenRes) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[7]
+	mi := &file_packet_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -623,7 +959,7 @@ func (x *TokenRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TokenRes.ProtoReflect.Descriptor instead.
 func (*TokenRes) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{7}
+	return file_packet_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *TokenRes) GetCode() int32 {
@@ -663,7 +999,7 @@ type Signout struct {
 func (x *Signout) Reset() {
 	*x = Signout{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[8]
+		mi := &file_packet_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -676,7 +1012,7 @@ func (x *Signout) String() string {
 func (*Signout) ProtoMessage() {}
 
 func (x *Signout) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[8]
+	mi := &file_packet_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -689,7 +1025,7 @@ func (x *Signout) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Signout.ProtoReflect.Descriptor instead.
 func (*Signout) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{8}
+	return file_packet_proto_rawDescGZIP(), []int{10}
 }
 
 type SignoutRes struct {
@@ -703,7 +1039,7 @@ type SignoutRes struct {
 func (x *SignoutRes) Reset() {
 	*x = SignoutRes{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[9]
+		mi := &file_packet_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -716,7 +1052,7 @@ func (x *SignoutRes) String() string {
 func (*SignoutRes) ProtoMessage() {}
 
 func (x *SignoutRes) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[9]
+	mi := &file_packet_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -729,7 +1065,7 @@ func (x *SignoutRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SignoutRes.ProtoReflect.Descriptor instead.
 func (*SignoutRes) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{9}
+	return file_packet_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *SignoutRes) GetCode() int32 {
@@ -739,32 +1075,35 @@ func (x *SignoutRes) GetCode() int32 {
 	return 0
 }
 
-type User struct {
+// 登录 session，每个 token 对应一个 session
+type Session struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Online   bool   `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	Id        uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Created   int64  `protobuf:"varint,2,opt,name=created,proto3" json:"created,omitempty"`
+	Refreshed int64  `protobuf:"varint,3,opt,name=refreshed,proto3" json:"refreshed,omitempty"`
+	Current   bool   `protobuf:"varint,4,opt,name=current,proto3" json:"current,omitempty"`
 }
 
-func (x *User) Reset() {
-	*x = User{}
+func (x *Session) Reset() {
+	*x = Session{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[10]
+		mi := &file_packet_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *User) String() string {
+func (x *Session) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*User) ProtoMessage() {}
+func (*Session) ProtoMessage() {}
 
-func (x *User) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[10]
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -775,48 +1114,62 @@ func (x *User) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use User.ProtoReflect.Descriptor instead.
-func (*User) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *User) GetUsername() string {
+func (x *Session) GetId() uint64 {
 	if x != nil {
-		return x.Username
+		return x.Id
 	}
-	return ""
+	return 0
 }
 
-func (x *User) GetOnline() bool {
+func (x *Session) GetCreated() int64 {
 	if x != nil {
-		return x.Online
+		return x.Created
 	}
-	return false
+	return 0
 }
 
-type Users struct {
+func (x *Session) GetRefreshed() int64 {
+	if x != nil {
+		return x.Refreshed
+	}
+	return 0
+}
+
+func (x *Session) GetCurrent() bool {
+	if x != nil {
+		return x.Current
+	}
+	return false
+}
+
+type Sessions struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *Users) Reset() {
-	*x = Users{}
+func (x *Sessions) Reset() {
+	*x = Sessions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[11]
+		mi := &file_packet_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Users) String() string {
+func (x *Sessions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Users) ProtoMessage() {}
+func (*Sessions) ProtoMessage() {}
 
-func (x *Users) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[11]
+func (x *Sessions) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -827,37 +1180,37 @@ func (x *Users) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Users.ProtoReflect.Descriptor instead.
-func (*Users) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use Sessions.ProtoReflect.Descriptor instead.
+func (*Sessions) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{13}
 }
 
-type UsersRes struct {
+type SessionsRes struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Code  int32   `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
-	Users []*User `protobuf:"bytes,2,rep,name=users,proto3" json:"users,omitempty"`
+	Code     int32      `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Sessions []*Session `protobuf:"bytes,2,rep,name=sessions,proto3" json:"sessions,omitempty"`
 }
 
-func (x *UsersRes) Reset() {
-	*x = UsersRes{}
+func (x *SessionsRes) Reset() {
+	*x = SessionsRes{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[12]
+		mi := &file_packet_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UsersRes) String() string {
+func (x *SessionsRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UsersRes) ProtoMessage() {}
+func (*SessionsRes) ProtoMessage() {}
 
-func (x *UsersRes) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[12]
+func (x *SessionsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -868,54 +1221,52 @@ func (x *UsersRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UsersRes.ProtoReflect.Descriptor instead.
-func (*UsersRes) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use SessionsRes.ProtoReflect.Descriptor instead.
+func (*SessionsRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *UsersRes) GetCode() int32 {
+func (x *SessionsRes) GetCode() int32 {
 	if x != nil {
 		return x.Code
 	}
 	return 0
 }
 
-func (x *UsersRes) GetUsers() []*User {
+func (x *SessionsRes) GetSessions() []*Session {
 	if x != nil {
-		return x.Users
+		return x.Sessions
 	}
 	return nil
 }
 
-type Msg struct {
+// others 为 true 时撤销除当前 session 之外的所有 session，否则撤销 id 指定的 session
+type Revoke struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id   int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Kind MsgKind `protobuf:"varint,2,opt,name=kind,proto3,enum=lib.MsgKind" json:"kind,omitempty"`
-	From string  `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
-	To   string  `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
-	Data []byte  `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Id     uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Others bool   `protobuf:"varint,2,opt,name=others,proto3" json:"others,omitempty"`
 }
 
-func (x *Msg) Reset() {
-	*x = Msg{}
+func (x *Revoke) Reset() {
+	*x = Revoke{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[13]
+		mi := &file_packet_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Msg) String() string {
+func (x *Revoke) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Msg) ProtoMessage() {}
+func (*Revoke) ProtoMessage() {}
 
-func (x *Msg) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[13]
+func (x *Revoke) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -926,71 +1277,107 @@ func (x *Msg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Msg.ProtoReflect.Descriptor instead.
-func (*Msg) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use Revoke.ProtoReflect.Descriptor instead.
+func (*Revoke) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *Msg) GetId() int64 {
+func (x *Revoke) GetId() uint64 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *Msg) GetKind() MsgKind {
+func (x *Revoke) GetOthers() bool {
 	if x != nil {
-		return x.Kind
+		return x.Others
 	}
-	return MsgKind_TEXT
+	return false
 }
 
-func (x *Msg) GetFrom() string {
-	if x != nil {
-		return x.From
+type RevokeRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Ids  []uint64 `protobuf:"varint,2,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+}
+
+func (x *RevokeRes) Reset() {
+	*x = RevokeRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return ""
 }
 
-func (x *Msg) GetTo() string {
+func (x *RevokeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeRes) ProtoMessage() {}
+
+func (x *RevokeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeRes.ProtoReflect.Descriptor instead.
+func (*RevokeRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RevokeRes) GetCode() int32 {
 	if x != nil {
-		return x.To
+		return x.Code
 	}
-	return ""
+	return 0
 }
 
-func (x *Msg) GetData() []byte {
+func (x *RevokeRes) GetIds() []uint64 {
 	if x != nil {
-		return x.Data
+		return x.Ids
 	}
 	return nil
 }
 
-type ErrRes struct {
+type User struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Online   bool   `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	Bot      bool   `protobuf:"varint,3,opt,name=bot,proto3" json:"bot,omitempty"`
 }
 
-func (x *ErrRes) Reset() {
-	*x = ErrRes{}
+func (x *User) Reset() {
+	*x = User{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[14]
+		mi := &file_packet_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ErrRes) String() string {
+func (x *User) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ErrRes) ProtoMessage() {}
+func (*User) ProtoMessage() {}
 
-func (x *ErrRes) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[14]
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1001,44 +1388,96 @@ func (x *ErrRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ErrRes.ProtoReflect.Descriptor instead.
-func (*ErrRes) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *ErrRes) GetCode() int32 {
+func (x *User) GetUsername() string {
 	if x != nil {
-		return x.Code
+		return x.Username
 	}
-	return 0
+	return ""
 }
 
-type Push struct {
+func (x *User) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *User) GetBot() bool {
+	if x != nil {
+		return x.Bot
+	}
+	return false
+}
+
+type Users struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+}
 
-	Kind PushKind `protobuf:"varint,1,opt,name=kind,proto3,enum=lib.PushKind" json:"kind,omitempty"`
-	Data []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+func (x *Users) Reset() {
+	*x = Users{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *Push) Reset() {
-	*x = Push{}
+func (x *Users) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Users) ProtoMessage() {}
+
+func (x *Users) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Users.ProtoReflect.Descriptor instead.
+func (*Users) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{18}
+}
+
+type UsersRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code  int32   `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Users []*User `protobuf:"bytes,2,rep,name=users,proto3" json:"users,omitempty"`
+}
+
+func (x *UsersRes) Reset() {
+	*x = UsersRes{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[15]
+		mi := &file_packet_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Push) String() string {
+func (x *UsersRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Push) ProtoMessage() {}
+func (*UsersRes) ProtoMessage() {}
 
-func (x *Push) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[15]
+func (x *UsersRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1049,51 +1488,56 @@ func (x *Push) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Push.ProtoReflect.Descriptor instead.
-func (*Push) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use UsersRes.ProtoReflect.Descriptor instead.
+func (*UsersRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *Push) GetKind() PushKind {
+func (x *UsersRes) GetCode() int32 {
 	if x != nil {
-		return x.Kind
+		return x.Code
 	}
-	return PushKind_ONLINE
+	return 0
 }
 
-func (x *Push) GetData() []byte {
+func (x *UsersRes) GetUsers() []*User {
 	if x != nil {
-		return x.Data
+		return x.Users
 	}
 	return nil
 }
 
-type Online struct {
+type Msg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Kind     OnlineKind `protobuf:"varint,1,opt,name=kind,proto3,enum=lib.OnlineKind" json:"kind,omitempty"`
-	Username string     `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Id     int64     `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind   MsgKind   `protobuf:"varint,2,opt,name=kind,proto3,enum=lib.MsgKind" json:"kind,omitempty"`
+	From   string    `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To     string    `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Data   []byte    `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Group  uint64    `protobuf:"varint,6,opt,name=group,proto3" json:"group,omitempty"`
+	Status MsgStatus `protobuf:"varint,7,opt,name=status,proto3,enum=lib.MsgStatus" json:"status,omitempty"`
 }
 
-func (x *Online) Reset() {
-	*x = Online{}
+func (x *Msg) Reset() {
+	*x = Msg{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_packet_proto_msgTypes[16]
+		mi := &file_packet_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Online) String() string {
+func (x *Msg) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Online) ProtoMessage() {}
+func (*Msg) ProtoMessage() {}
 
-func (x *Online) ProtoReflect() protoreflect.Message {
-	mi := &file_packet_proto_msgTypes[16]
+func (x *Msg) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1104,100 +1548,1460 @@ func (x *Online) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Online.ProtoReflect.Descriptor instead.
-func (*Online) Descriptor() ([]byte, []int) {
-	return file_packet_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use Msg.ProtoReflect.Descriptor instead.
+func (*Msg) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *Online) GetKind() OnlineKind {
+func (x *Msg) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Msg) GetKind() MsgKind {
 	if x != nil {
 		return x.Kind
 	}
-	return OnlineKind_ON
+	return MsgKind_TEXT
 }
 
-func (x *Online) GetUsername() string {
+func (x *Msg) GetFrom() string {
 	if x != nil {
-		return x.Username
+		return x.From
 	}
 	return ""
 }
 
-var File_packet_proto protoreflect.FileDescriptor
+func (x *Msg) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
 
-var file_packet_proto_rawDesc = []byte{
-	0x0a, 0x0c, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03,
-	0x6c, 0x69, 0x62, 0x22, 0x4f, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x21, 0x0a,
-	0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x6c, 0x69,
+func (x *Msg) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Msg) GetGroup() uint64 {
+	if x != nil {
+		return x.Group
+	}
+	return 0
+}
+
+func (x *Msg) GetStatus() MsgStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MsgStatus_SENT
+}
+
+type MsgRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Id   int64 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *MsgRes) Reset() {
+	*x = MsgRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MsgRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgRes) ProtoMessage() {}
+
+func (x *MsgRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgRes.ProtoReflect.Descriptor instead.
+func (*MsgRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MsgRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *MsgRes) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Receipt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status MsgStatus `protobuf:"varint,1,opt,name=status,proto3,enum=lib.MsgStatus" json:"status,omitempty"`
+	Peer   string    `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+	Ids    []int64   `protobuf:"varint,3,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Receipt) GetStatus() MsgStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MsgStatus_SENT
+}
+
+func (x *Receipt) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *Receipt) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type Group struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Owner   string   `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Members []string `protobuf:"bytes,4,rep,name=members,proto3" json:"members,omitempty"`
+}
+
+func (x *Group) Reset() {
+	*x = Group{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Group) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Group) ProtoMessage() {}
+
+func (x *Group) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Group.ProtoReflect.Descriptor instead.
+func (*Group) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Group) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Group) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Group) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Group) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type GroupCreate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Members []string `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+}
+
+func (x *GroupCreate) Reset() {
+	*x = GroupCreate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupCreate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCreate) ProtoMessage() {}
+
+func (x *GroupCreate) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCreate.ProtoReflect.Descriptor instead.
+func (*GroupCreate) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GroupCreate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GroupCreate) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type GroupInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Members []string `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+}
+
+func (x *GroupInvite) Reset() {
+	*x = GroupInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupInvite) ProtoMessage() {}
+
+func (x *GroupInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupInvite.ProtoReflect.Descriptor instead.
+func (*GroupInvite) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GroupInvite) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GroupInvite) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type GroupKick struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Members []string `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+}
+
+func (x *GroupKick) Reset() {
+	*x = GroupKick{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupKick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupKick) ProtoMessage() {}
+
+func (x *GroupKick) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupKick.ProtoReflect.Descriptor instead.
+func (*GroupKick) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GroupKick) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GroupKick) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type GroupLeave struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GroupLeave) Reset() {
+	*x = GroupLeave{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupLeave) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupLeave) ProtoMessage() {}
+
+func (x *GroupLeave) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupLeave.ProtoReflect.Descriptor instead.
+func (*GroupLeave) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GroupLeave) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GroupRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code  int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Group *Group `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+}
+
+func (x *GroupRes) Reset() {
+	*x = GroupRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupRes) ProtoMessage() {}
+
+func (x *GroupRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupRes.ProtoReflect.Descriptor instead.
+func (*GroupRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GroupRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *GroupRes) GetGroup() *Group {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+type Groups struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *Groups) Reset() {
+	*x = Groups{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Groups) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Groups) ProtoMessage() {}
+
+func (x *Groups) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Groups.ProtoReflect.Descriptor instead.
+func (*Groups) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{29}
+}
+
+type GroupsRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code   int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Groups []*Group `protobuf:"bytes,2,rep,name=groups,proto3" json:"groups,omitempty"`
+}
+
+func (x *GroupsRes) Reset() {
+	*x = GroupsRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupsRes) ProtoMessage() {}
+
+func (x *GroupsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupsRes.ProtoReflect.Descriptor instead.
+func (*GroupsRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *GroupsRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *GroupsRes) GetGroups() []*Group {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type History struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Peer   string `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+	Group  uint64 `protobuf:"varint,2,opt,name=group,proto3" json:"group,omitempty"`
+	Cursor int64  `protobuf:"varint,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit  int32  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *History) Reset() {
+	*x = History{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *History) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*History) ProtoMessage() {}
+
+func (x *History) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use History.ProtoReflect.Descriptor instead.
+func (*History) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *History) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *History) GetGroup() uint64 {
+	if x != nil {
+		return x.Group
+	}
+	return 0
+}
+
+func (x *History) GetCursor() int64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+func (x *History) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type HistoryRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Msgs []*Msg `protobuf:"bytes,2,rep,name=msgs,proto3" json:"msgs,omitempty"`
+}
+
+func (x *HistoryRes) Reset() {
+	*x = HistoryRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRes) ProtoMessage() {}
+
+func (x *HistoryRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRes.ProtoReflect.Descriptor instead.
+func (*HistoryRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *HistoryRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *HistoryRes) GetMsgs() []*Msg {
+	if x != nil {
+		return x.Msgs
+	}
+	return nil
+}
+
+// 发布身份公钥。backup 为使用密码派生密钥加密的身份私钥，同一用户的其他设备登录时下载并导入，所有设备使用相同的身份密钥
+type PublishKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key    []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Backup []byte `protobuf:"bytes,2,opt,name=backup,proto3" json:"backup,omitempty"`
+}
+
+func (x *PublishKey) Reset() {
+	*x = PublishKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PublishKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishKey) ProtoMessage() {}
+
+func (x *PublishKey) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishKey.ProtoReflect.Descriptor instead.
+func (*PublishKey) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PublishKey) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *PublishKey) GetBackup() []byte {
+	if x != nil {
+		return x.Backup
+	}
+	return nil
+}
+
+type GetKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *GetKey) Reset() {
+	*x = GetKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetKey) ProtoMessage() {}
+
+func (x *GetKey) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetKey.ProtoReflect.Descriptor instead.
+func (*GetKey) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetKey) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// 只有查询自己的公钥时才返回 backup
+type KeyRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code     int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Key      []byte `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Backup   []byte `protobuf:"bytes,4,opt,name=backup,proto3" json:"backup,omitempty"`
+}
+
+func (x *KeyRes) Reset() {
+	*x = KeyRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRes) ProtoMessage() {}
+
+func (x *KeyRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRes.ProtoReflect.Descriptor instead.
+func (*KeyRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *KeyRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *KeyRes) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *KeyRes) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *KeyRes) GetBackup() []byte {
+	if x != nil {
+		return x.Backup
+	}
+	return nil
+}
+
+// 服务器即将关闭，客户端应在 retry_after 秒后重新连接
+type GoAway struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RetryAfter int32  `protobuf:"varint,1,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"`
+	Reason     string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *GoAway) Reset() {
+	*x = GoAway{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GoAway) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoAway) ProtoMessage() {}
+
+func (x *GoAway) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoAway.ProtoReflect.Descriptor instead.
+func (*GoAway) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GoAway) GetRetryAfter() int32 {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return 0
+}
+
+func (x *GoAway) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ErrRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *ErrRes) Reset() {
+	*x = ErrRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrRes) ProtoMessage() {}
+
+func (x *ErrRes) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrRes.ProtoReflect.Descriptor instead.
+func (*ErrRes) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ErrRes) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+type Push struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind PushKind `protobuf:"varint,1,opt,name=kind,proto3,enum=lib.PushKind" json:"kind,omitempty"`
+	Data []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Push) Reset() {
+	*x = Push{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Push) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Push) ProtoMessage() {}
+
+func (x *Push) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Push.ProtoReflect.Descriptor instead.
+func (*Push) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *Push) GetKind() PushKind {
+	if x != nil {
+		return x.Kind
+	}
+	return PushKind_ONLINE
+}
+
+func (x *Push) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Online struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind     OnlineKind `protobuf:"varint,1,opt,name=kind,proto3,enum=lib.OnlineKind" json:"kind,omitempty"`
+	Username string     `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *Online) Reset() {
+	*x = Online{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Online) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Online) ProtoMessage() {}
+
+func (x *Online) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Online.ProtoReflect.Descriptor instead.
+func (*Online) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *Online) GetKind() OnlineKind {
+	if x != nil {
+		return x.Kind
+	}
+	return OnlineKind_ON
+}
+
+func (x *Online) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// 集群节点之间转发的事件，只在节点之间的内部连接上使用。连接建立后接受方先发送带有随机 nonce 的 CHALLENGE，发起方回复 HELLO，node 为发送方节点 id，mac 为使用集群密钥计算的 HMAC-SHA256(nonce | node)。username 和 id 为事件相关的用户名和帐号 id
+type ClusterEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind     ClusterKind `protobuf:"varint,1,opt,name=kind,proto3,enum=lib.ClusterKind" json:"kind,omitempty"`
+	Node     int64       `protobuf:"varint,2,opt,name=node,proto3" json:"node,omitempty"`
+	Mac      []byte      `protobuf:"bytes,3,opt,name=mac,proto3" json:"mac,omitempty"`
+	Username string      `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	Id       uint64      `protobuf:"varint,5,opt,name=id,proto3" json:"id,omitempty"`
+	Msg      *Msg        `protobuf:"bytes,6,opt,name=msg,proto3" json:"msg,omitempty"`
+	Push     *Push       `protobuf:"bytes,7,opt,name=push,proto3" json:"push,omitempty"`
+	Tids     []uint64    `protobuf:"varint,8,rep,packed,name=tids,proto3" json:"tids,omitempty"`
+	Nonce    []byte      `protobuf:"bytes,9,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (x *ClusterEvent) Reset() {
+	*x = ClusterEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_packet_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClusterEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterEvent) ProtoMessage() {}
+
+func (x *ClusterEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_packet_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterEvent.ProtoReflect.Descriptor instead.
+func (*ClusterEvent) Descriptor() ([]byte, []int) {
+	return file_packet_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ClusterEvent) GetKind() ClusterKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ClusterKind_CLUSTER_HELLO
+}
+
+func (x *ClusterEvent) GetNode() int64 {
+	if x != nil {
+		return x.Node
+	}
+	return 0
+}
+
+func (x *ClusterEvent) GetMac() []byte {
+	if x != nil {
+		return x.Mac
+	}
+	return nil
+}
+
+func (x *ClusterEvent) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ClusterEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ClusterEvent) GetMsg() *Msg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+func (x *ClusterEvent) GetPush() *Push {
+	if x != nil {
+		return x.Push
+	}
+	return nil
+}
+
+func (x *ClusterEvent) GetTids() []uint64 {
+	if x != nil {
+		return x.Tids
+	}
+	return nil
+}
+
+func (x *ClusterEvent) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+var File_packet_proto protoreflect.FileDescriptor
+
+var file_packet_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03,
+	0x6c, 0x69, 0x62, 0x22, 0x4f, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x21, 0x0a,
+	0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x6c, 0x69,
 	0x62, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64,
 	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64,
 	0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x64, 0x61, 0x74, 0x61, 0x22, 0x20, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x07,
-	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70,
-	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x20, 0x0a, 0x04, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x18,
-	0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x3e, 0x0a, 0x04, 0x41, 0x75, 0x74, 0x68,
-	0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x70, 0x61, 0x73, 0x73, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
-	0x70, 0x61, 0x73, 0x73, 0x68, 0x61, 0x73, 0x68, 0x22, 0x27, 0x0a, 0x06, 0x53, 0x69, 0x67, 0x6e,
-	0x75, 0x70, 0x12, 0x1d, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x09, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74,
-	0x68, 0x22, 0x27, 0x0a, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x04, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x22, 0x53, 0x0a, 0x05, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x22, 0xb1, 0x01, 0x0a, 0x08, 0x48, 0x65,
+	0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x69, 0x6e, 0x5f, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x66,
+	0x72, 0x61, 0x6d, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0c, 0x6d, 0x61, 0x78, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x22, 0x20, 0x0a,
+	0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22,
+	0x20, 0x0a, 0x04, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x22, 0x3e, 0x0a, 0x04, 0x41, 0x75, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x68, 0x61, 0x73,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x68, 0x61, 0x73,
+	0x68, 0x22, 0x39, 0x0a, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x75, 0x70, 0x12, 0x1d, 0x0a, 0x04, 0x61,
 	0x75, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x6c, 0x69, 0x62, 0x2e,
-	0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x22, 0x1d, 0x0a, 0x05, 0x54, 0x6f,
-	0x6b, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x60, 0x0a, 0x08, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65,
-	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65,
-	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x09, 0x0a, 0x07, 0x53,
-	0x69, 0x67, 0x6e, 0x6f, 0x75, 0x74, 0x22, 0x20, 0x0a, 0x0a, 0x53, 0x69, 0x67, 0x6e, 0x6f, 0x75,
-	0x74, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x3a, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72,
-	0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06,
-	0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6f, 0x6e,
-	0x6c, 0x69, 0x6e, 0x65, 0x22, 0x07, 0x0a, 0x05, 0x55, 0x73, 0x65, 0x72, 0x73, 0x22, 0x3f, 0x0a,
-	0x08, 0x55, 0x73, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a,
-	0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x6c,
-	0x69, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x22, 0x6f,
-	0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x20, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0e, 0x32, 0x0c, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x4d, 0x73, 0x67, 0x4b, 0x69, 0x6e,
-	0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74,
-	0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x64,
-	0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22,
-	0x1c, 0x0a, 0x06, 0x45, 0x72, 0x72, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x3d, 0x0a,
-	0x04, 0x50, 0x75, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x4b, 0x69,
-	0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x49, 0x0a, 0x06,
-	0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x23, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x4f, 0x6e, 0x6c, 0x69, 0x6e,
-	0x65, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75,
-	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
-	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x2a, 0x7e, 0x0a, 0x08, 0x50, 0x61, 0x63, 0x6b, 0x4b,
-	0x69, 0x6e, 0x64, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x4f, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x07, 0x0a,
-	0x03, 0x45, 0x52, 0x52, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x52, 0x45, 0x53, 0x10, 0x02, 0x12,
-	0x08, 0x0a, 0x04, 0x50, 0x55, 0x53, 0x48, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x4d, 0x53, 0x47,
-	0x10, 0x04, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06,
-	0x53, 0x49, 0x47, 0x4e, 0x55, 0x50, 0x10, 0x06, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x49, 0x47, 0x4e,
-	0x49, 0x4e, 0x10, 0x07, 0x12, 0x09, 0x0a, 0x05, 0x54, 0x4f, 0x4b, 0x45, 0x4e, 0x10, 0x08, 0x12,
-	0x0b, 0x0a, 0x07, 0x53, 0x49, 0x47, 0x4e, 0x4f, 0x55, 0x54, 0x10, 0x09, 0x12, 0x09, 0x0a, 0x05,
-	0x55, 0x53, 0x45, 0x52, 0x53, 0x10, 0x0a, 0x2a, 0x13, 0x0a, 0x07, 0x4d, 0x73, 0x67, 0x4b, 0x69,
-	0x6e, 0x64, 0x12, 0x08, 0x0a, 0x04, 0x54, 0x45, 0x58, 0x54, 0x10, 0x00, 0x2a, 0x16, 0x0a, 0x08,
-	0x50, 0x75, 0x73, 0x68, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0a, 0x0a, 0x06, 0x4f, 0x4e, 0x4c, 0x49,
-	0x4e, 0x45, 0x10, 0x00, 0x2a, 0x1d, 0x0a, 0x0a, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x4b, 0x69,
-	0x6e, 0x64, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4e, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x4f, 0x46,
-	0x46, 0x10, 0x01, 0x42, 0x20, 0x5a, 0x1e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x68, 0x75, 0x6f, 0x79, 0x69, 0x6a, 0x69, 0x65, 0x2f, 0x47, 0x6f, 0x43, 0x68, 0x61,
-	0x74, 0x2f, 0x6c, 0x69, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x6f,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x62, 0x6f, 0x74, 0x22, 0x27, 0x0a, 0x06,
+	0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52,
+	0x04, 0x61, 0x75, 0x74, 0x68, 0x22, 0x1d, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x60, 0x0a, 0x08, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x09, 0x0a, 0x07, 0x53, 0x69, 0x67, 0x6e, 0x6f, 0x75,
+	0x74, 0x22, 0x20, 0x0a, 0x0a, 0x53, 0x69, 0x67, 0x6e, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x22, 0x6b, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x72,
+	0x65, 0x73, 0x68, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x66,
+	0x72, 0x65, 0x73, 0x68, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74,
+	0x22, 0x0a, 0x0a, 0x08, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x4b, 0x0a, 0x0b,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12,
+	0x28, 0x0a, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0c, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x30, 0x0a, 0x06, 0x52, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x73, 0x22, 0x31, 0x0a, 0x09, 0x52,
+	0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x69, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x04, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x4c,
+	0x0a, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x6f,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x62, 0x6f, 0x74, 0x22, 0x07, 0x0a, 0x05,
+	0x55, 0x73, 0x65, 0x72, 0x73, 0x22, 0x3f, 0x0a, 0x08, 0x55, 0x73, 0x65, 0x72, 0x73, 0x52, 0x65,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52,
+	0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x22, 0xad, 0x01, 0x0a, 0x03, 0x4d, 0x73, 0x67, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x20,
+	0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0c, 0x2e, 0x6c,
+	0x69, 0x62, 0x2e, 0x4d, 0x73, 0x67, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x74, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75,
+	0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x26,
+	0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e,
+	0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x4d, 0x73, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x2c, 0x0a, 0x06, 0x4d, 0x73, 0x67, 0x52, 0x65, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0x57, 0x0a, 0x07, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12,
+	0x26, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x0e, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x4d, 0x73, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x65, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x65, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x69,
+	0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x03, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x5b, 0x0a,
+	0x05, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77,
+	0x6e, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72,
+	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x22, 0x3b, 0x0a, 0x0b, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x22, 0x37, 0x0a, 0x0b, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73,
+	0x22, 0x35, 0x0a, 0x09, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4b, 0x69, 0x63, 0x6b, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x22, 0x1c, 0x0a, 0x0a, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x4c, 0x65, 0x61, 0x76, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x02, 0x69, 0x64, 0x22, 0x40, 0x0a, 0x08, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x65,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x20, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x22, 0x08, 0x0a, 0x06, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x73, 0x22, 0x43, 0x0a, 0x09, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x52, 0x65, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x22, 0x0a, 0x06, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x06,
+	0x67, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x22, 0x61, 0x0a, 0x07, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x65, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x70, 0x65, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x63,
+	0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x75, 0x72,
+	0x73, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x3e, 0x0a, 0x0a, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x04, 0x6d,
+	0x73, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x6c, 0x69, 0x62, 0x2e,
+	0x4d, 0x73, 0x67, 0x52, 0x04, 0x6d, 0x73, 0x67, 0x73, 0x22, 0x36, 0x0a, 0x0a, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x73, 0x68, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x62, 0x61, 0x63, 0x6b, 0x75,
+	0x70, 0x22, 0x24, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x62, 0x0a, 0x06, 0x4b, 0x65, 0x79, 0x52, 0x65,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x22, 0x41, 0x0a, 0x06, 0x47,
+	0x6f, 0x41, 0x77, 0x61, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x61,
+	0x66, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x72, 0x65, 0x74, 0x72,
+	0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x1c,
+	0x0a, 0x06, 0x45, 0x72, 0x72, 0x52, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x3d, 0x0a, 0x04,
+	0x50, 0x75, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x4b, 0x69, 0x6e,
+	0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x49, 0x0a, 0x06, 0x4f,
+	0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x23, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65,
+	0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xeb, 0x01, 0x0a, 0x0c, 0x43, 0x6c, 0x75, 0x73, 0x74,
+	0x65, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x43, 0x6c, 0x75, 0x73,
+	0x74, 0x65, 0x72, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6e, 0x6f, 0x64,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x6d, 0x61, 0x63, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x1a, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x6c,
+	0x69, 0x62, 0x2e, 0x4d, 0x73, 0x67, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12, 0x1d, 0x0a, 0x04, 0x70,
+	0x75, 0x73, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x6c, 0x69, 0x62, 0x2e,
+	0x50, 0x75, 0x73, 0x68, 0x52, 0x04, 0x70, 0x75, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69,
+	0x64, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x04, 0x52, 0x04, 0x74, 0x69, 0x64, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x2a, 0xc5, 0x02, 0x0a, 0x08, 0x50, 0x61, 0x63, 0x6b, 0x4b, 0x69, 0x6e,
+	0x64, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x4f, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x45,
+	0x52, 0x52, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x52, 0x45, 0x53, 0x10, 0x02, 0x12, 0x08, 0x0a,
+	0x04, 0x50, 0x55, 0x53, 0x48, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x4d, 0x53, 0x47, 0x10, 0x04,
+	0x12, 0x08, 0x0a, 0x04, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x49,
+	0x47, 0x4e, 0x55, 0x50, 0x10, 0x06, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x49, 0x47, 0x4e, 0x49, 0x4e,
+	0x10, 0x07, 0x12, 0x09, 0x0a, 0x05, 0x54, 0x4f, 0x4b, 0x45, 0x4e, 0x10, 0x08, 0x12, 0x0b, 0x0a,
+	0x07, 0x53, 0x49, 0x47, 0x4e, 0x4f, 0x55, 0x54, 0x10, 0x09, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x53,
+	0x45, 0x52, 0x53, 0x10, 0x0a, 0x12, 0x10, 0x0a, 0x0c, 0x47, 0x52, 0x4f, 0x55, 0x50, 0x5f, 0x43,
+	0x52, 0x45, 0x41, 0x54, 0x45, 0x10, 0x0b, 0x12, 0x10, 0x0a, 0x0c, 0x47, 0x52, 0x4f, 0x55, 0x50,
+	0x5f, 0x49, 0x4e, 0x56, 0x49, 0x54, 0x45, 0x10, 0x0c, 0x12, 0x0e, 0x0a, 0x0a, 0x47, 0x52, 0x4f,
+	0x55, 0x50, 0x5f, 0x4b, 0x49, 0x43, 0x4b, 0x10, 0x0d, 0x12, 0x0f, 0x0a, 0x0b, 0x47, 0x52, 0x4f,
+	0x55, 0x50, 0x5f, 0x4c, 0x45, 0x41, 0x56, 0x45, 0x10, 0x0e, 0x12, 0x0a, 0x0a, 0x06, 0x47, 0x52,
+	0x4f, 0x55, 0x50, 0x53, 0x10, 0x0f, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x52,
+	0x59, 0x10, 0x10, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x43, 0x45, 0x49, 0x50, 0x54, 0x10, 0x11,
+	0x12, 0x0f, 0x0a, 0x0b, 0x50, 0x55, 0x42, 0x4c, 0x49, 0x53, 0x48, 0x5f, 0x4b, 0x45, 0x59, 0x10,
+	0x12, 0x12, 0x0b, 0x0a, 0x07, 0x47, 0x45, 0x54, 0x5f, 0x4b, 0x45, 0x59, 0x10, 0x13, 0x12, 0x0c,
+	0x0a, 0x08, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x53, 0x10, 0x14, 0x12, 0x0a, 0x0a, 0x06,
+	0x52, 0x45, 0x56, 0x4f, 0x4b, 0x45, 0x10, 0x15, 0x12, 0x0a, 0x0a, 0x06, 0x47, 0x4f, 0x41, 0x57,
+	0x41, 0x59, 0x10, 0x16, 0x12, 0x09, 0x0a, 0x05, 0x48, 0x45, 0x4c, 0x4c, 0x4f, 0x10, 0x17, 0x12,
+	0x0b, 0x0a, 0x07, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x10, 0x18, 0x2a, 0x1f, 0x0a, 0x07,
+	0x4d, 0x73, 0x67, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x08, 0x0a, 0x04, 0x54, 0x45, 0x58, 0x54, 0x10,
+	0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x45, 0x41, 0x4c, 0x45, 0x44, 0x10, 0x01, 0x2a, 0x2e, 0x0a,
+	0x09, 0x4d, 0x73, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x45,
+	0x4e, 0x54, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45, 0x52, 0x45,
+	0x44, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x52, 0x45, 0x41, 0x44, 0x10, 0x02, 0x2a, 0x27, 0x0a,
+	0x08, 0x50, 0x75, 0x73, 0x68, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0a, 0x0a, 0x06, 0x4f, 0x4e, 0x4c,
+	0x49, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x4d, 0x53, 0x47, 0x5f, 0x52, 0x45, 0x43,
+	0x45, 0x49, 0x50, 0x54, 0x10, 0x01, 0x2a, 0x1d, 0x0a, 0x0a, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65,
+	0x4b, 0x69, 0x6e, 0x64, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4e, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03,
+	0x4f, 0x46, 0x46, 0x10, 0x01, 0x2a, 0xd2, 0x01, 0x0a, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x11, 0x0a, 0x0d, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52,
+	0x5f, 0x48, 0x45, 0x4c, 0x4c, 0x4f, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x4c, 0x55, 0x53,
+	0x54, 0x45, 0x52, 0x5f, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x4c,
+	0x55, 0x53, 0x54, 0x45, 0x52, 0x5f, 0x4f, 0x4e, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x02, 0x12, 0x13,
+	0x0a, 0x0f, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x5f, 0x4f, 0x46, 0x46, 0x4c, 0x49, 0x4e,
+	0x45, 0x10, 0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x5f, 0x4d,
+	0x53, 0x47, 0x10, 0x04, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x5f,
+	0x53, 0x59, 0x4e, 0x43, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45,
+	0x52, 0x5f, 0x50, 0x55, 0x53, 0x48, 0x10, 0x06, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4c, 0x55, 0x53,
+	0x54, 0x45, 0x52, 0x5f, 0x42, 0x52, 0x4f, 0x41, 0x44, 0x43, 0x41, 0x53, 0x54, 0x10, 0x07, 0x12,
+	0x12, 0x0a, 0x0e, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x5f, 0x52, 0x45, 0x56, 0x4f, 0x4b,
+	0x45, 0x10, 0x08, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4c, 0x55, 0x53, 0x54, 0x45, 0x52, 0x5f, 0x43,
+	0x48, 0x41, 0x4c, 0x4c, 0x45, 0x4e, 0x47, 0x45, 0x10, 0x09, 0x42, 0x20, 0x5a, 0x1e, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x75, 0x6f, 0x79, 0x69, 0x6a, 0x69,
+	0x65, 0x2f, 0x47, 0x6f, 0x43, 0x68, 0x61, 0x74, 0x2f, 0x6c, 0x69, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1212,44 +3016,79 @@ func file_packet_proto_rawDescGZIP() []byte {
 	return file_packet_proto_rawDescData
 }
 
-var file_packet_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_packet_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_packet_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_packet_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
 var file_packet_proto_goTypes = []interface{}{
-	(PackKind)(0),      // 0: lib.PackKind
-	(MsgKind)(0),       // 1: lib.MsgKind
-	(PushKind)(0),      // 2: lib.PushKind
-	(OnlineKind)(0),    // 3: lib.OnlineKind
-	(*Packet)(nil),     // 4: lib.Packet
-	(*Ping)(nil),       // 5: lib.Ping
-	(*Pong)(nil),       // 6: lib.Pong
-	(*Auth)(nil),       // 7: lib.Auth
-	(*Signup)(nil),     // 8: lib.Signup
-	(*Signin)(nil),     // 9: lib.Signin
-	(*Token)(nil),      // 10: lib.Token
-	(*TokenRes)(nil),   // 11: lib.TokenRes
-	(*Signout)(nil),    // 12: lib.Signout
-	(*SignoutRes)(nil), // 13: lib.SignoutRes
-	(*User)(nil),       // 14: lib.User
-	(*Users)(nil),      // 15: lib.Users
-	(*UsersRes)(nil),   // 16: lib.UsersRes
-	(*Msg)(nil),        // 17: lib.Msg
-	(*ErrRes)(nil),     // 18: lib.ErrRes
-	(*Push)(nil),       // 19: lib.Push
-	(*Online)(nil),     // 20: lib.Online
+	(PackKind)(0),        // 0: lib.PackKind
+	(MsgKind)(0),         // 1: lib.MsgKind
+	(MsgStatus)(0),       // 2: lib.MsgStatus
+	(PushKind)(0),        // 3: lib.PushKind
+	(OnlineKind)(0),      // 4: lib.OnlineKind
+	(ClusterKind)(0),     // 5: lib.ClusterKind
+	(*Packet)(nil),       // 6: lib.Packet
+	(*Hello)(nil),        // 7: lib.Hello
+	(*HelloRes)(nil),     // 8: lib.HelloRes
+	(*Ping)(nil),         // 9: lib.Ping
+	(*Pong)(nil),         // 10: lib.Pong
+	(*Auth)(nil),         // 11: lib.Auth
+	(*Signup)(nil),       // 12: lib.Signup
+	(*Signin)(nil),       // 13: lib.Signin
+	(*Token)(nil),        // 14: lib.Token
+	(*TokenRes)(nil),     // 15: lib.TokenRes
+	(*Signout)(nil),      // 16: lib.Signout
+	(*SignoutRes)(nil),   // 17: lib.SignoutRes
+	(*Session)(nil),      // 18: lib.Session
+	(*Sessions)(nil),     // 19: lib.Sessions
+	(*SessionsRes)(nil),  // 20: lib.SessionsRes
+	(*Revoke)(nil),       // 21: lib.Revoke
+	(*RevokeRes)(nil),    // 22: lib.RevokeRes
+	(*User)(nil),         // 23: lib.User
+	(*Users)(nil),        // 24: lib.Users
+	(*UsersRes)(nil),     // 25: lib.UsersRes
+	(*Msg)(nil),          // 26: lib.Msg
+	(*MsgRes)(nil),       // 27: lib.MsgRes
+	(*Receipt)(nil),      // 28: lib.Receipt
+	(*Group)(nil),        // 29: lib.Group
+	(*GroupCreate)(nil),  // 30: lib.GroupCreate
+	(*GroupInvite)(nil),  // 31: lib.GroupInvite
+	(*GroupKick)(nil),    // 32: lib.GroupKick
+	(*GroupLeave)(nil),   // 33: lib.GroupLeave
+	(*GroupRes)(nil),     // 34: lib.GroupRes
+	(*Groups)(nil),       // 35: lib.Groups
+	(*GroupsRes)(nil),    // 36: lib.GroupsRes
+	(*History)(nil),      // 37: lib.History
+	(*HistoryRes)(nil),   // 38: lib.HistoryRes
+	(*PublishKey)(nil),   // 39: lib.PublishKey
+	(*GetKey)(nil),       // 40: lib.GetKey
+	(*KeyRes)(nil),       // 41: lib.KeyRes
+	(*GoAway)(nil),       // 42: lib.GoAway
+	(*ErrRes)(nil),       // 43: lib.ErrRes
+	(*Push)(nil),         // 44: lib.Push
+	(*Online)(nil),       // 45: lib.Online
+	(*ClusterEvent)(nil), // 46: lib.ClusterEvent
 }
 var file_packet_proto_depIdxs = []int32{
 	0,  // 0: lib.Packet.kind:type_name -> lib.PackKind
-	7,  // 1: lib.Signup.auth:type_name -> lib.Auth
-	7,  // 2: lib.Signin.auth:type_name -> lib.Auth
-	14, // 3: lib.UsersRes.users:type_name -> lib.User
-	1,  // 4: lib.Msg.kind:type_name -> lib.MsgKind
-	2,  // 5: lib.Push.kind:type_name -> lib.PushKind
-	3,  // 6: lib.Online.kind:type_name -> lib.OnlineKind
-	7,  // [7:7] is the sub-list for method output_type
-	7,  // [7:7] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	11, // 1: lib.Signup.auth:type_name -> lib.Auth
+	11, // 2: lib.Signin.auth:type_name -> lib.Auth
+	18, // 3: lib.SessionsRes.sessions:type_name -> lib.Session
+	23, // 4: lib.UsersRes.users:type_name -> lib.User
+	1,  // 5: lib.Msg.kind:type_name -> lib.MsgKind
+	2,  // 6: lib.Msg.status:type_name -> lib.MsgStatus
+	2,  // 7: lib.Receipt.status:type_name -> lib.MsgStatus
+	29, // 8: lib.GroupRes.group:type_name -> lib.Group
+	29, // 9: lib.GroupsRes.groups:type_name -> lib.Group
+	26, // 10: lib.HistoryRes.msgs:type_name -> lib.Msg
+	3,  // 11: lib.Push.kind:type_name -> lib.PushKind
+	4,  // 12: lib.Online.kind:type_name -> lib.OnlineKind
+	5,  // 13: lib.ClusterEvent.kind:type_name -> lib.ClusterKind
+	26, // 14: lib.ClusterEvent.msg:type_name -> lib.Msg
+	44, // 15: lib.ClusterEvent.push:type_name -> lib.Push
+	16, // [16:16] is the sub-list for method output_type
+	16, // [16:16] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_packet_proto_init() }
@@ -1270,7 +3109,31 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Hello); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HelloRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Ping); i {
 			case 0:
 				return &v.state
@@ -1282,7 +3145,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Pong); i {
 			case 0:
 				return &v.state
@@ -1294,7 +3157,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Auth); i {
 			case 0:
 				return &v.state
@@ -1306,7 +3169,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Signup); i {
 			case 0:
 				return &v.state
@@ -1318,7 +3181,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Signin); i {
 			case 0:
 				return &v.state
@@ -1330,7 +3193,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Token); i {
 			case 0:
 				return &v.state
@@ -1342,7 +3205,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TokenRes); i {
 			case 0:
 				return &v.state
@@ -1354,7 +3217,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Signout); i {
 			case 0:
 				return &v.state
@@ -1366,7 +3229,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SignoutRes); i {
 			case 0:
 				return &v.state
@@ -1378,7 +3241,67 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Session); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Sessions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SessionsRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Revoke); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*User); i {
 			case 0:
 				return &v.state
@@ -1390,7 +3313,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Users); i {
 			case 0:
 				return &v.state
@@ -1402,7 +3325,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UsersRes); i {
 			case 0:
 				return &v.state
@@ -1414,7 +3337,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Msg); i {
 			case 0:
 				return &v.state
@@ -1426,7 +3349,199 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MsgRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Receipt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Group); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupCreate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupKick); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupLeave); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Groups); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GroupsRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*History); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PublishKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GoAway); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_packet_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ErrRes); i {
 			case 0:
 				return &v.state
@@ -1438,7 +3553,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Push); i {
 			case 0:
 				return &v.state
@@ -1450,7 +3565,7 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
-		file_packet_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+		file_packet_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Online); i {
 			case 0:
 				return &v.state
@@ -1462,14 +3577,26 @@ func file_packet_proto_init() {
 				return nil
 			}
 		}
+		file_packet_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClusterEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_packet_proto_rawDesc,
-			NumEnums:      4,
-			NumMessages:   17,
+			NumEnums:      6,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
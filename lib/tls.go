@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// 生成自签名证书，用于本地开发测试。hosts 可以是域名或 IP
+func NewSelfSignedCert(hosts []string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"GoChat Dev"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		// 自签名证书同时作为 CA 证书，客户端可以直接用它验证服务器
+		IsCA: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+// 返回证书 DER 编码的 sha256 指纹（十六进制）
+func CertFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// 客户端 tls 配置。caFile 不为空时使用该 CA 证书验证服务器；fingerprint 不为空时只信任指纹匹配的服务器证书
+func ClientTLSConfig(serverName, caFile, fingerprint string) (config *tls.Config, err error) {
+	config = &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+
+	if len(caFile) > 0 {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid ca file")
+		}
+		config.RootCAs = pool
+	}
+
+	if len(fingerprint) > 0 {
+		fingerprint = strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+		// 证书固定，不再校验证书链，只比较服务器证书指纹
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) > 0 && CertFingerprint(rawCerts[0]) == fingerprint {
+				return nil
+			}
+			return errors.New("server certificate fingerprint mismatch")
+		}
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package lib
+
+// 当前协议版本。增加新的 packet 类型时不需要升级版本，双方会忽略或拒绝不支持的 packet；不兼容的协议修改才需要升级版本
+const PROTO_VERSION uint32 = 1
+
+// 协议特性，握手时双方交换各自支持的特性
+const (
+	FEATURE_E2E      = "e2e"
+	FEATURE_SESSIONS = "sessions"
+	FEATURE_RECEIPTS = "receipts"
+	FEATURE_GOAWAY   = "goaway"
+)
+
+// 当前版本支持的所有特性
+var Features = []string{FEATURE_E2E, FEATURE_SESSIONS, FEATURE_RECEIPTS, FEATURE_GOAWAY}
+
+// 构建信息，编译时可通过 -ldflags "-X github.com/huoyijie/GoChat/lib.Build=..." 设置
+var Build = "dev"
+
+// 判断 features 是否包含 feature
+func HasFeature(features []string, feature string) bool {
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 健康检查等待存储和 hub 响应的最长时间
+const ADMIN_CHECK_TIMEOUT = 2 * time.Second
+
+// 所有监听器都已开始接受连接，服务器开始关闭时设置为 false，负载均衡不再转发新连接
+var serverReady atomic.Bool
+
+// 当前连接，连接建立时加入 liveConns，断开时删除
+type conn_info_t struct {
+	sid    uint64
+	remote string
+	since  time.Time
+	// 待发送的 packet
+	packChan chan *lib.Packet
+	// 待发送的 push 和消息
+	c chan proto.Message
+}
+
+// sid -> *conn_info_t
+var liveConns sync.Map
+
+// /sessions 返回的一个连接
+type session_info_t struct {
+	Sid uint64 `json:"sid"`
+	// 未登录的连接为空
+	AccId          uint64    `json:"acc_id,omitempty"`
+	Username       string    `json:"username,omitempty"`
+	RemoteAddr     string    `json:"remote_addr"`
+	ConnectedSince time.Time `json:"connected_since"`
+	SendQueue      int       `json:"send_queue"`
+	PushQueue      int       `json:"push_queue"`
+}
+
+// 管理接口，只应在内网访问
+type admin_t struct {
+	eventChan chan<- event_i
+	storage   store_i
+}
+
+// 监听 c.AdminAddr 并提供健康检查、pprof 和 session 查询接口
+func serveAdmin(c *config_t, eventChan chan<- event_i, storage store_i) (*http.Server, error) {
+	ln, err := net.Listen("tcp", c.AdminAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &admin_t{eventChan, storage}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", a.healthz)
+	mux.HandleFunc("/readyz", a.readyz)
+	mux.HandleFunc("/sessions", a.sessions)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("admin listener stopped", "addr", c.AdminAddr, "err", err)
+		}
+	}()
+	return srv, nil
+}
+
+// 向 hub 发送事件，超时未处理时认为 hub 已阻塞
+func (a *admin_t) send(ctx context.Context, e event_i) error {
+	select {
+	case a.eventChan <- e:
+		return nil
+	case <-ctx.Done():
+		return errors.New("push hub not responding")
+	}
+}
+
+// 检查 hub 能否及时处理事件
+func (a *admin_t) probeHub(ctx context.Context) error {
+	done := make(chan struct{})
+	if err := a.send(ctx, &e_probe_t{done}); err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("push hub not responding")
+	}
+}
+
+// 依次执行检查，返回每项检查的结果，任意一项失败时返回 503
+func (a *admin_t) check(w http.ResponseWriter, r *http.Request, checks map[string]func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(r.Context(), ADMIN_CHECK_TIMEOUT)
+	defer cancel()
+
+	status := http.StatusOK
+	results := make(map[string]string, len(checks))
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			results[name] = err.Error()
+		} else {
+			results[name] = "ok"
+		}
+	}
+	a.reply(w, status, results)
+}
+
+// 存活检查，hub 阻塞时需要重启
+func (a *admin_t) healthz(w http.ResponseWriter, r *http.Request) {
+	a.check(w, r, map[string]func(ctx context.Context) error{
+		"hub": a.probeHub,
+	})
+}
+
+// 就绪检查，存储可以访问、监听器正在接受连接并且 hub 没有阻塞
+func (a *admin_t) readyz(w http.ResponseWriter, r *http.Request) {
+	a.check(w, r, map[string]func(ctx context.Context) error{
+		"hub":     a.probeHub,
+		"storage": a.storage.Ping,
+		"listener": func(ctx context.Context) error {
+			if !serverReady.Load() {
+				return errors.New("not accepting connections")
+			}
+			return nil
+		},
+	})
+}
+
+// 按 sid 排序返回当前所有连接
+func (a *admin_t) sessions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ADMIN_CHECK_TIMEOUT)
+	defer cancel()
+
+	// 登录用户由 hub 维护
+	reply := make(chan map[uint64]session_t, 1)
+	var sessions map[uint64]session_t
+	err := a.send(ctx, &e_sessions_t{reply})
+	if err == nil {
+		select {
+		case sessions = <-reply:
+		case <-ctx.Done():
+			err = errors.New("push hub not responding")
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	list := []session_info_t{}
+	liveConns.Range(func(key, value any) bool {
+		conn := value.(*conn_info_t)
+		info := session_info_t{
+			Sid:            conn.sid,
+			RemoteAddr:     conn.remote,
+			ConnectedSince: conn.since,
+			SendQueue:      len(conn.packChan),
+			PushQueue:      len(conn.c),
+		}
+		if s, found := sessions[conn.sid]; found {
+			info.AccId, info.Username = s.id, s.username
+		}
+		list = append(list, info)
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Sid < list[j].Sid })
+	a.reply(w, http.StatusOK, list)
+}
+
+// 以 json 格式返回响应
+func (a *admin_t) reply(w http.ResponseWriter, status int, res any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		slog.Warn("write admin response", "err", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+func adminGet(t *testing.T, handler http.HandlerFunc, path string, res any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if err := json.NewDecoder(rec.Body).Decode(res); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code
+}
+
+// hub 没有处理事件时健康检查失败，监听器未就绪时就绪检查失败
+func TestAdminHealth(t *testing.T) {
+	defer serverReady.Store(serverReady.Load())
+
+	// 没有 hub 读取事件
+	wedged := &admin_t{make(chan event_i), newMemoryStore()}
+	var results map[string]string
+	if code := adminGet(t, wedged.healthz, "/healthz", &results); code != http.StatusServiceUnavailable || results["hub"] == "ok" {
+		t.Errorf("wedged hub: %d %v", code, results)
+	}
+
+	eventChan := make(chan event_i, conf.EventBuffer)
+	go handlePush(eventChan, make(chan *lib.Push), newMemoryStore())
+	a := &admin_t{eventChan, newMemoryStore()}
+	if code := adminGet(t, a.healthz, "/healthz", &results); code != http.StatusOK {
+		t.Errorf("healthz: %d %v", code, results)
+	}
+
+	serverReady.Store(false)
+	if code := adminGet(t, a.readyz, "/readyz", &results); code != http.StatusServiceUnavailable || results["listener"] == "ok" || results["storage"] != "ok" {
+		t.Errorf("readyz before listening: %d %v", code, results)
+	}
+	serverReady.Store(true)
+	if code := adminGet(t, a.readyz, "/readyz", &results); code != http.StatusOK {
+		t.Errorf("readyz: %d %v", code, results)
+	}
+}
+
+// 返回所有连接，已登录的连接带有用户名
+func TestAdminSessions(t *testing.T) {
+	storage := newMemoryStore()
+	eventChan := make(chan event_i, conf.EventBuffer)
+	go handlePush(eventChan, make(chan *lib.Push), storage)
+	a := &admin_t{eventChan, storage}
+
+	since := time.Now().Truncate(time.Second)
+	for sid := uint64(101); sid <= 102; sid++ {
+		liveConns.Store(sid, &conn_info_t{sid: sid, remote: "127.0.0.1:5000", since: since, packChan: make(chan *lib.Packet, 1)})
+		defer liveConns.Delete(sid)
+	}
+	server, _ := newSession(102, "alice", eventChan, storage)
+	defer server.Close()
+
+	var list []session_info_t
+	// 等待上线事件处理完成
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if code := adminGet(t, a.sessions, "/sessions", &list); code != http.StatusOK {
+			t.Fatalf("sessions: %d", code)
+		}
+		if len(list) == 2 && list[1].Username == "alice" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(list) != 2 || list[0].Sid != 101 || list[0].Username != "" || list[1].AccId != 102 || list[1].Username != "alice" ||
+		list[0].RemoteAddr != "127.0.0.1:5000" || !list[0].ConnectedSince.Equal(since) {
+		t.Errorf("sessions = %+v", list)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// HTTP API 请求没有长连接，使用该 sid 处理请求。连接 id 从 1 开始分配
+const API_SID = 0
+
+// HTTP API 接口，请求和响应都是 protojson 格式，请求会交给对应的 biz 处理
+type api_route_t struct {
+	kind lib.PackKind
+	// 创建请求对象
+	req func() proto.Message
+	// 是否需要 token
+	auth bool
+}
+
+var apiRoutes = map[string]api_route_t{
+	"/api/signin":  {lib.PackKind_SIGNIN, func() proto.Message { return &lib.Signin{} }, false},
+	"/api/users":   {lib.PackKind_USERS, func() proto.Message { return &lib.Users{} }, true},
+	"/api/send":    {lib.PackKind_MSG, func() proto.Message { return &lib.Msg{} }, true},
+	"/api/history": {lib.PackKind_HISTORY, func() proto.Message { return &lib.History{} }, true},
+}
+
+// 实现 post 接口，保存 biz 返回的同步响应，忽略其他 packet
+type api_poster_t struct {
+	res proto.Message
+}
+
+// Handle implements lib.Post
+func (p *api_poster_t) Handle(req, res proto.Message) error {
+	p.res = res
+	return nil
+}
+
+// Send implements lib.Post
+func (p *api_poster_t) Send(req proto.Message) error {
+	return nil
+}
+
+// Close implements lib.Post
+func (p *api_poster_t) Close() {}
+
+var _ lib.Post = (*api_poster_t)(nil)
+
+// HTTP API 处理器
+type api_t struct {
+	eventChan chan<- event_i
+	pushChan  chan<- *lib.Push
+	storage   store_i
+	node      *snowflake.Node
+	webhooks  *webhooks_t
+}
+
+// 监听 c.APIAddr 并处理 HTTP API 请求，config 不为 nil 时使用 tls
+func serveAPI(c *config_t, config *tls.Config, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage store_i, node *snowflake.Node, webhooks *webhooks_t) (*http.Server, error) {
+	ln, err := listen(c.APIAddr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", &api_t{eventChan, pushChan, storage, node, webhooks})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("api listener stopped", "addr", c.APIAddr, "err", err)
+		}
+	}()
+	return srv, nil
+}
+
+// 验证 Authorization: Bearer <token> 请求头，token 为 TokenRes 中 base64 编码的 token。HTTP API 不会刷新 token
+func (a *api_t) auth(r *http.Request) (account *Account, code lib.ErrCode, ok bool) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, lib.Err_Forbidden, false
+	}
+
+	token, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		return nil, lib.Err_Base64_Decode, false
+	}
+
+	id, tid, serial, expired, err := ParseToken(token)
+	if err != nil {
+		return nil, lib.Err_Parse_Token, false
+	}
+	if expired {
+		return nil, lib.Err_Token_Expired, false
+	}
+
+	// session 已撤销，或者 token 已经被刷新过
+	session, err := a.storage.GetSession(tid)
+	if err != nil || session.AccountId != id || (session.Serial != serial && !inGrace(session, serial)) {
+		return nil, lib.Err_Token_Revoked, false
+	}
+
+	if account, err = a.storage.GetAccountById(id); err != nil {
+		return nil, lib.Err_Acc_Not_Exist, false
+	}
+	return account, 0, true
+}
+
+// 以 protojson 格式返回响应
+func (a *api_t) reply(w http.ResponseWriter, status int, res proto.Message) {
+	bytes, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(res)
+	if err != nil {
+		slog.Error("marshal api response", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bytes)
+}
+
+// ServeHTTP implements http.Handler
+func (a *api_t) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, found := apiRoutes[r.URL.Path]
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		accId uint64
+		accUN string
+	)
+	if route.auth {
+		account, code, ok := a.auth(r)
+		if !ok {
+			a.reply(w, http.StatusUnauthorized, &lib.ErrRes{Code: authFailed(code).Val()})
+			return
+		}
+		accId, accUN = account.Id, account.Username
+	}
+
+	// 请求体为空时使用空请求对象
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(conf.MaxFrameSize)))
+	if err != nil {
+		a.reply(w, http.StatusRequestEntityTooLarge, &lib.ErrRes{Code: lib.Err_Frame_Too_Large.Val()})
+		return
+	}
+	req := route.req()
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, req); err != nil {
+			a.reply(w, http.StatusBadRequest, &lib.ErrRes{Code: lib.Err_Unmarshal.Val()})
+			return
+		}
+	}
+	if signin, ok := req.(*lib.Signin); ok && signin.Auth == nil {
+		a.reply(w, http.StatusBadRequest, &lib.ErrRes{Code: lib.Err_Unmarshal.Val()})
+		return
+	}
+
+	data, err := lib.Marshal(req)
+	if err != nil {
+		a.reply(w, http.StatusInternalServerError, &lib.ErrRes{Code: lib.Err_Unmarshal.Val()})
+		return
+	}
+
+	// 与 tcp 连接使用相同的 biz 处理请求，消息同样会转发给接收者在线的 session
+	poster := &api_poster_t{}
+	biz, err := kindToBiz(route.kind, initialAPIBase(poster, a.eventChan, a.pushChan, a.storage), a.node, a.webhooks)
+	if err != nil {
+		a.reply(w, http.StatusNotFound, &lib.ErrRes{Code: lib.Err_Unknown_Kind.Val()})
+		return
+	}
+	if err := biz.do(&lib.Packet{Kind: route.kind, Data: data}, &accId, &accUN); err != nil || poster.res == nil {
+		slog.Error("handle api request", "path", r.URL.Path, "kind", route.kind.String(), "acc_id", accId, "username", accUN, "err", err)
+		a.reply(w, http.StatusInternalServerError, &lib.ErrRes{})
+		return
+	}
+
+	// 响应 code 小于 0 时返回 400，方便调用方判断请求是否成功
+	status := http.StatusOK
+	if res, ok := poster.res.(interface{ GetCode() int32 }); ok && res.GetCode() < 0 {
+		status = http.StatusBadRequest
+	}
+	a.reply(w, status, poster.res)
+}
+
+var _ http.Handler = (*api_t)(nil)
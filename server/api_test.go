@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huoyijie/GoChat/lib"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// 启动 HTTP API 服务器，创建 alice 和 bob 两个帐号，密码都是 hello123
+func newAPIServer(t *testing.T) (string, store_i) {
+	t.Helper()
+	storage := newMemoryStore()
+	passhash := sha256.Sum256([]byte("hello123"))
+	passhashAndBcrypt, err := bcrypt.GenerateFromPassword(passhash[:], bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"alice", "bob"} {
+		account := &Account{Username: username, PasshashAndBcrypt: base64.StdEncoding.EncodeToString(passhashAndBcrypt)}
+		if err := storage.NewAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eventChan := make(chan event_i, conf.EventBuffer)
+	pushChan := make(chan *lib.Push, conf.EventBuffer)
+	go handlePush(eventChan, pushChan, storage)
+
+	srv := httptest.NewServer(&api_t{eventChan, pushChan, storage, testNode, nil})
+	t.Cleanup(srv.Close)
+	return srv.URL, storage
+}
+
+// 以 protojson 格式发送请求，token 不为空时带上 Authorization 请求头，返回 HTTP 状态码
+func apiPost(t *testing.T, url, path string, token []byte, req, res proto.Message) int {
+	t.Helper()
+	body, err := protojson.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := http.NewRequest(http.MethodPost, url+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) > 0 {
+		r.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString(token))
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protojson.Unmarshal(data, res); err != nil {
+		t.Fatalf("%s: %v, body %s", path, err, data)
+	}
+	return resp.StatusCode
+}
+
+// 通过 HTTP API 登录
+func apiSignin(t *testing.T, url, username string) *lib.TokenRes {
+	t.Helper()
+	passhash := sha256.Sum256([]byte("hello123"))
+	res := &lib.TokenRes{}
+	if status := apiPost(t, url, "/api/signin", nil, &lib.Signin{Auth: &lib.Auth{Username: username, Passhash: passhash[:]}}, res); status != http.StatusOK || res.Code < 0 {
+		t.Fatalf("signin %s: %d %v", username, status, res)
+	}
+	return res
+}
+
+// 返回 token 中的 session id
+func tokenTid(t *testing.T, token []byte) uint64 {
+	t.Helper()
+	_, tid, _, _, err := ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tid
+}
+
+// 登录后发送消息，接收者查询历史消息。多次登录复用同一个 session
+func TestAPISendHistory(t *testing.T) {
+	testKeys(t)
+	url, storage := newAPIServer(t)
+
+	alice := apiSignin(t, url, "alice")
+	if again := apiSignin(t, url, "alice"); tokenTid(t, again.Token) != tokenTid(t, alice.Token) {
+		t.Error("api signin should reuse the session")
+	}
+	if sessions, _ := storage.GetSessions(alice.Id); len(sessions) != 1 {
+		t.Errorf("sessions = %v, want 1", sessions)
+	}
+
+	msgRes := &lib.MsgRes{}
+	if status := apiPost(t, url, "/api/send", alice.Token, &lib.Msg{Kind: lib.MsgKind_TEXT, To: "bob", Data: []byte("hi")}, msgRes); status != http.StatusOK || msgRes.Code < 0 || msgRes.Id == 0 {
+		t.Fatalf("send: %d %v", status, msgRes)
+	}
+	if status := apiPost(t, url, "/api/send", alice.Token, &lib.Msg{To: "nobody", Data: []byte("hi")}, &lib.MsgRes{}); status != http.StatusBadRequest {
+		t.Errorf("send to unknown account: %d", status)
+	}
+
+	bob := apiSignin(t, url, "bob")
+	historyRes := &lib.HistoryRes{}
+	if status := apiPost(t, url, "/api/history", bob.Token, &lib.History{Peer: "alice"}, historyRes); status != http.StatusOK || historyRes.Code < 0 {
+		t.Fatalf("history: %d %v", status, historyRes)
+	}
+	if len(historyRes.Msgs) != 1 || historyRes.Msgs[0].Id != msgRes.Id || historyRes.Msgs[0].From != "alice" || string(historyRes.Msgs[0].Data) != "hi" {
+		t.Errorf("history = %v", historyRes.Msgs)
+	}
+}
+
+// 没有 token 或者 session 已撤销时返回 401
+func TestAPIAuth(t *testing.T) {
+	testKeys(t)
+	url, storage := newAPIServer(t)
+	alice := apiSignin(t, url, "alice")
+
+	errRes := &lib.ErrRes{}
+	if status := apiPost(t, url, "/api/history", nil, &lib.History{}, errRes); status != http.StatusUnauthorized || errRes.Code != lib.Err_Forbidden.Val() {
+		t.Errorf("missing token: %d %v", status, errRes)
+	}
+
+	tid := tokenTid(t, alice.Token)
+	if _, err := storage.RevokeSessions(alice.Id, []uint64{tid}); err != nil {
+		t.Fatal(err)
+	}
+	errRes = &lib.ErrRes{}
+	if status := apiPost(t, url, "/api/send", alice.Token, &lib.Msg{To: "bob", Data: []byte("hi")}, errRes); status != http.StatusUnauthorized || errRes.Code != lib.Err_Token_Revoked.Val() {
+		t.Errorf("revoked token: %d %v", status, errRes)
+	}
+
+	// 撤销后重新登录创建新的 session
+	if again := apiSignin(t, url, "alice"); tokenTid(t, again.Token) == tid {
+		t.Errorf("signin after revoke reused the revoked session")
+	}
+}
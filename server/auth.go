@@ -2,60 +2,73 @@ package main
 
 import (
 	"encoding/binary"
-	"encoding/hex"
-	"log"
-	"os"
+	"errors"
 	"time"
 
 	"github.com/huoyijie/GoChat/lib"
 )
 
-const (
-	DEFAULT_SECRET_KEY = "3e367a60ddc0699ea2f486717d5dcd174c4dee0bcf1855065ab74c348e550b78"
-)
+// token 明文: 用户 id | session id | session 序号 | 生成时间
+const tokenLen = 32
 
-func secretKey() (secretKey string) {
-	secretKey, found := os.LookupEnv("SECRET_KEY")
-	if !found {
-		secretKey = DEFAULT_SECRET_KEY
-	}
-	return
-}
+// token 刷新后上一个 token 仍然可用的时间，客户端没有收到刷新后的 token 或者多个连接同时验证 token 时不会被迫重新登录
+const TOKEN_GRACE = 30 * time.Second
 
-func GetSecretKey() *[32]byte {
-	key, err := hex.DecodeString(secretKey())
-	if err != nil {
-		log.Fatal(err)
-	}
-	return (*[32]byte)(key)
+// serial 是否为 session 上一个序号，并且刷新后还没有超过宽限期
+func inGrace(session *Session, serial uint64) bool {
+	return serial+1 == session.Serial && time.Since(session.UpdatedAt) < TOKEN_GRACE
 }
 
-func GenerateToken(id uint64) (token []byte, err error) {
-	gcm, err := lib.NewGCM(GetSecretKey())
+// 生成 token，tid 为 session id，serial 为 session 当前序号。每次刷新 token 时序号加 1，旧 token 随之失效
+//
+// token 格式: 密钥版本 (4 字节) | 密文
+func GenerateToken(id, tid, serial uint64) (token []byte, err error) {
+	kid, key := tokenKeys.currentKey()
+	gcm, err := lib.NewGCM(key)
 	if err != nil {
 		return
 	}
 
-	bytes := make([]byte, 16)
+	bytes := make([]byte, tokenLen)
 	binary.BigEndian.PutUint64(bytes, id)
-	binary.BigEndian.PutUint64(bytes[8:], uint64(time.Now().Unix()))
-	token = lib.Encrypt(bytes, gcm)
+	binary.BigEndian.PutUint64(bytes[8:], tid)
+	binary.BigEndian.PutUint64(bytes[16:], serial)
+	binary.BigEndian.PutUint64(bytes[24:], uint64(time.Now().Unix()))
+	token = binary.BigEndian.AppendUint32(nil, kid)
+	token = append(token, lib.Encrypt(bytes, gcm)...)
 	return
 }
 
-func ParseToken(token []byte) (id uint64, expired bool, err error) {
-	gcm, err := lib.NewGCM(GetSecretKey())
+func ParseToken(token []byte) (id, tid, serial uint64, expired bool, err error) {
+	if len(token) < 4 {
+		err = errors.New("invalid token")
+		return
+	}
+
+	key, found := tokenKeys.key(binary.BigEndian.Uint32(token))
+	if !found {
+		err = errors.New("unknown token key")
+		return
+	}
+
+	gcm, err := lib.NewGCM(key)
 	if err != nil {
 		return
 	}
 
-	bytes, err := lib.Decrypt(token, gcm)
+	bytes, err := lib.Decrypt(token[4:], gcm)
 	if err != nil {
 		return
 	}
+	if len(bytes) != tokenLen {
+		err = errors.New("invalid token")
+		return
+	}
 
 	id = binary.BigEndian.Uint64(bytes)
-	genTime := binary.BigEndian.Uint64(bytes[8:])
-	expired = time.Since(time.Unix(int64(genTime), 0)) > 30*24*time.Hour
+	tid = binary.BigEndian.Uint64(bytes[8:])
+	serial = binary.BigEndian.Uint64(bytes[16:])
+	genTime := binary.BigEndian.Uint64(bytes[24:])
+	expired = time.Since(time.Unix(int64(genTime), 0)) > conf.TokenTTL
 	return
 }
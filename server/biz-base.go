@@ -23,24 +23,59 @@ type biz_base_t struct {
 	poster    lib.Post
 	eventChan chan<- event_i
 	pushChan  chan<- *lib.Push
-	c         chan *lib.Push
-	storage   *storage_t
+	c         chan proto.Message
+	storage   store_i
+	// 当前连接登录 session id
+	tid *uint64
+	// hub 关闭该 channel 时断开连接
+	kick chan struct{}
+	// 客户端握手信息，Version 为协商后的协议版本，未握手的旧版本客户端为 0
+	hello *lib.Hello
 }
 
-func initialBase(sid uint64, poster lib.Post, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage *storage_t) biz_base_t {
+func initialBase(sid uint64, poster lib.Post, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage store_i) biz_base_t {
 	return biz_base_t{
 		sid,
 		poster,
 		eventChan,
 		pushChan,
-		make(chan *lib.Push, 1024),
+		make(chan proto.Message, conf.SessionBuffer),
 		storage,
+		new(uint64),
+		make(chan struct{}),
+		&lib.Hello{},
 	}
 }
 
-// 生成 token 并向客户端发送 TokenRes packet
-func (b *biz_base_t) handleAuth(pack *lib.Packet, account *Account, accId *uint64, accUN *string) error {
-	token, err := GenerateToken(account.Id)
+// HTTP API 请求没有长连接，不需要发送队列和 kick channel
+func initialAPIBase(poster lib.Post, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage store_i) biz_base_t {
+	return biz_base_t{
+		sid:       API_SID,
+		poster:    poster,
+		eventChan: eventChan,
+		pushChan:  pushChan,
+		storage:   storage,
+		tid:       new(uint64),
+		hello:     &lib.Hello{},
+	}
+}
+
+// 生成 token 并向客户端发送 TokenRes packet。session 为 nil 时创建新的登录 session，否则为刷新后的 session
+func (b *biz_base_t) handleAuth(pack *lib.Packet, account *Account, session *Session, accId *uint64, accUN *string) error {
+	if session == nil {
+		var err error
+		// HTTP API 每次请求都可能重新登录，复用同一个 session，避免 session 数量不断增长
+		if b.sid == API_SID {
+			session, err = b.storage.APISession(account.Id)
+		} else {
+			session, err = b.storage.NewSession(account.Id)
+		}
+		if err != nil {
+			return b.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Gen_Token.Val()})
+		}
+	}
+
+	token, err := GenerateToken(account.Id, session.Id, session.Serial)
 	if err != nil {
 		return b.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Gen_Token.Val()})
 	}
@@ -53,23 +88,36 @@ func (b *biz_base_t) handleAuth(pack *lib.Packet, account *Account, accId *uint6
 	// 更新全局变量
 	*accId = account.Id
 	*accUN = account.Username
+	*b.tid = session.Id
 
-	// 更新表
-	b.storage.UpdateOnline(*accId, true)
+	// HTTP API 请求没有长连接，不上线也不转发未读消息
+	if b.sid == API_SID {
+		return nil
+	}
 
-	// 上线事件
-	b.eventChan <- &e_online_t{b.sid, b.c}
+	// 上线事件，之后发送给当前用户的新消息会直接转发到当前 session。用户的第一个 session 上线时会更新在线状态并发送上线提醒
+	b.eventChan <- &e_online_t{b.sid, *accId, *b.tid, *accUN, b.c, b.kick}
 
-	// 上线提醒
-	bytes, err := lib.Marshal(&lib.Online{Kind: lib.OnlineKind_ON, Username: *accUN})
+	// 转发离线期间收到的未读消息。上线事件之后才查询存储，确保消息不会丢失，客户端会忽略重复的消息
+	msgList, err := b.storage.GetMsgList(*accUN)
 	if err != nil {
 		return err
 	}
-	b.pushChan <- &lib.Push{
-		Kind: lib.PushKind_ONLINE,
-		Data: bytes,
+	for i := range msgList {
+		if err := b.poster.Send(msgList[i].toLibMsg()); err != nil {
+			return err
+		}
 	}
-	return nil
+	return sendReceipts(b.eventChan, lib.MsgStatus_DELIVERED, *accUN, msgList)
+}
+
+// 查询群组信息并向客户端发送 GroupRes packet
+func (b *biz_base_t) handleGroup(pack *lib.Packet, id uint64) error {
+	group, err := b.storage.GetGroupInfo(id)
+	if err != nil {
+		return b.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Group_Not_Exist.Val()})
+	}
+	return b.poster.Handle(pack, &lib.GroupRes{Group: group})
 }
 
 // 反序列化请求对象
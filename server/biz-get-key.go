@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理查询用户端到端加密公钥请求
+type biz_get_key_t struct {
+	biz_base_t
+}
+
+func initialGetKey(base biz_base_t) *biz_get_key_t {
+	return &biz_get_key_t{base}
+}
+
+func (g *biz_get_key_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := g.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return g.poster.Handle(pack, &lib.KeyRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	getKey := &lib.GetKey{}
+	if err := g.unmarshal(pack, getKey); err != nil {
+		return err
+	}
+
+	account, err := g.storage.GetAccountByUN(getKey.Username)
+	if err != nil || len(account.PublicKey) == 0 {
+		return g.poster.Handle(pack, &lib.KeyRes{Code: lib.Err_Key_Not_Exist.Val(), Username: getKey.Username})
+	}
+
+	keyRes := &lib.KeyRes{Username: account.Username, Key: account.PublicKey}
+	// 私钥备份只返回给用户自己的其他设备
+	if account.Id == *accId {
+		keyRes.Backup = account.KeyBackup
+	}
+	return g.poster.Handle(pack, keyRes)
+}
+
+var _ biz_i = (*biz_get_key_t)(nil)
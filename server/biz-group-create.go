@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理创建群组请求
+type biz_group_create_t struct {
+	biz_base_t
+}
+
+func initialGroupCreate(base biz_base_t) *biz_group_create_t {
+	return &biz_group_create_t{base}
+}
+
+func (gc *biz_group_create_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := gc.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return gc.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	groupCreate := &lib.GroupCreate{}
+	if err := gc.unmarshal(pack, groupCreate); err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(groupCreate.Name)
+	if len(name) == 0 {
+		return gc.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Create_Group.Val()})
+	}
+
+	group := &Group{Name: name, Owner: *accUN}
+	if err := gc.storage.NewGroup(group, groupCreate.Members); err != nil {
+		return gc.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Create_Group.Val()})
+	}
+
+	return gc.handleGroup(pack, group.Id)
+}
+
+var _ biz_i = (*biz_group_create_t)(nil)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理邀请用户加入群组请求，群成员都可以邀请
+type biz_group_invite_t struct {
+	biz_base_t
+}
+
+func initialGroupInvite(base biz_base_t) *biz_group_invite_t {
+	return &biz_group_invite_t{base}
+}
+
+func (gi *biz_group_invite_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := gi.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return gi.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	groupInvite := &lib.GroupInvite{}
+	if err := gi.unmarshal(pack, groupInvite); err != nil {
+		return err
+	}
+
+	if _, err := gi.storage.GetGroup(groupInvite.Id); err != nil {
+		return gi.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Group_Not_Exist.Val()})
+	}
+
+	if member, err := gi.storage.IsGroupMember(groupInvite.Id, *accUN); err != nil {
+		return gi.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Update_Group.Val()})
+	} else if !member {
+		return gi.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Not_Group_Member.Val()})
+	}
+
+	if err := gi.storage.AddGroupMembers(groupInvite.Id, groupInvite.Members); err != nil {
+		return gi.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Update_Group.Val()})
+	}
+
+	return gi.handleGroup(pack, groupInvite.Id)
+}
+
+var _ biz_i = (*biz_group_invite_t)(nil)
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理把用户移出群组请求，只有群主可以移除群成员
+type biz_group_kick_t struct {
+	biz_base_t
+}
+
+func initialGroupKick(base biz_base_t) *biz_group_kick_t {
+	return &biz_group_kick_t{base}
+}
+
+func (gk *biz_group_kick_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := gk.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return gk.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	groupKick := &lib.GroupKick{}
+	if err := gk.unmarshal(pack, groupKick); err != nil {
+		return err
+	}
+
+	group, err := gk.storage.GetGroup(groupKick.Id)
+	if err != nil {
+		return gk.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Group_Not_Exist.Val()})
+	}
+
+	if group.Owner != *accUN {
+		return gk.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Not_Group_Owner.Val()})
+	}
+
+	// 群主不能移除自己，只能退出群组
+	members := make([]string, 0, len(groupKick.Members))
+	for _, member := range groupKick.Members {
+		if member != group.Owner {
+			members = append(members, member)
+		}
+	}
+
+	if len(members) > 0 {
+		if err := gk.storage.RemoveGroupMembers(group.Id, members); err != nil {
+			return gk.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Update_Group.Val()})
+		}
+	}
+
+	return gk.handleGroup(pack, group.Id)
+}
+
+var _ biz_i = (*biz_group_kick_t)(nil)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理退出群组请求
+type biz_group_leave_t struct {
+	biz_base_t
+}
+
+func initialGroupLeave(base biz_base_t) *biz_group_leave_t {
+	return &biz_group_leave_t{base}
+}
+
+func (gl *biz_group_leave_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := gl.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return gl.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	groupLeave := &lib.GroupLeave{}
+	if err := gl.unmarshal(pack, groupLeave); err != nil {
+		return err
+	}
+
+	if _, err := gl.storage.GetGroup(groupLeave.Id); err != nil {
+		return gl.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Group_Not_Exist.Val()})
+	}
+
+	if member, err := gl.storage.IsGroupMember(groupLeave.Id, *accUN); err != nil {
+		return gl.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Update_Group.Val()})
+	} else if !member {
+		return gl.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Not_Group_Member.Val()})
+	}
+
+	if err := gl.storage.RemoveGroupMembers(groupLeave.Id, []string{*accUN}); err != nil {
+		return gl.poster.Handle(pack, &lib.GroupRes{Code: lib.Err_Update_Group.Val()})
+	}
+
+	return gl.poster.Handle(pack, &lib.GroupRes{})
+}
+
+var _ biz_i = (*biz_group_leave_t)(nil)
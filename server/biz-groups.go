@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理获取群组列表请求
+type biz_groups_t struct {
+	biz_base_t
+}
+
+func initialGroups(base biz_base_t) *biz_groups_t {
+	return &biz_groups_t{base}
+}
+
+func (g *biz_groups_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := g.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return g.poster.Handle(pack, &lib.GroupsRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	groups, err := g.storage.GetGroups(*accUN)
+	if err != nil {
+		return g.poster.Handle(pack, &lib.GroupsRes{Code: lib.Err_Get_Groups.Val()})
+	}
+
+	return g.poster.Handle(pack, &lib.GroupsRes{Groups: groups})
+}
+
+var _ biz_i = (*biz_groups_t)(nil)
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理握手请求，协商协议版本并交换双方支持的特性
+type biz_hello_t struct {
+	biz_base_t
+}
+
+func initialHello(base biz_base_t) *biz_hello_t {
+	return &biz_hello_t{base}
+}
+
+func (h *biz_hello_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := h.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	hello := &lib.Hello{}
+	if err := lib.Unmarshal(pack.Data, hello); err != nil {
+		return err
+	}
+
+	res := &lib.HelloRes{
+		Version:    lib.PROTO_VERSION,
+		MinVersion: uint32(conf.MinVersion),
+		Features:   lib.Features,
+		Build:      lib.Build,
+		// 客户端据此限制发送的 packet 长度
+		MaxFrameSize: uint32(conf.MaxFrameSize),
+	}
+
+	// 客户端版本过低，返回错误码后断开连接
+	if hello.Version < res.MinVersion {
+		res.Code = lib.Err_Version_Unsupported.Val()
+		if err := h.poster.Handle(pack, res); err != nil {
+			return err
+		}
+		return fmt.Errorf("unsupported protocol version %d, build %s", hello.Version, hello.Build)
+	}
+
+	// 客户端版本较新时按照服务器版本通信
+	proto.Merge(h.hello, hello)
+	if h.hello.Version > lib.PROTO_VERSION {
+		h.hello.Version = lib.PROTO_VERSION
+	}
+	return h.poster.Handle(pack, res)
+}
+
+var _ biz_i = (*biz_hello_t)(nil)
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// 每页历史消息默认条数
+	DEFAULT_HISTORY_LIMIT = 20
+	// 每页历史消息最大条数
+	MAX_HISTORY_LIMIT = 100
+)
+
+// 处理分页查询历史消息请求
+type biz_history_t struct {
+	biz_base_t
+}
+
+func initialHistory(base biz_base_t) *biz_history_t {
+	return &biz_history_t{base}
+}
+
+func (h *biz_history_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := h.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return h.poster.Handle(pack, &lib.HistoryRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	history := &lib.History{}
+	if err := h.unmarshal(pack, history); err != nil {
+		return err
+	}
+
+	limit := int(history.Limit)
+	if limit <= 0 {
+		limit = DEFAULT_HISTORY_LIMIT
+	} else if limit > MAX_HISTORY_LIMIT {
+		limit = MAX_HISTORY_LIMIT
+	}
+
+	msgList, err := h.storage.GetHistory(*accUN, history.Peer, history.Group, history.Cursor, limit)
+	if err != nil {
+		return h.poster.Handle(pack, &lib.HistoryRes{Code: lib.Err_Get_History.Val()})
+	}
+
+	msgs := make([]*lib.Msg, len(msgList))
+	for i := range msgList {
+		msgs[i] = msgList[i].toLibMsg()
+	}
+
+	return h.poster.Handle(pack, &lib.HistoryRes{Msgs: msgs})
+}
+
+var _ biz_i = (*biz_history_t)(nil)
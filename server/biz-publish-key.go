@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理发布端到端加密公钥请求
+type biz_publish_key_t struct {
+	biz_base_t
+}
+
+func initialPublishKey(base biz_base_t) *biz_publish_key_t {
+	return &biz_publish_key_t{base}
+}
+
+func (p *biz_publish_key_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := p.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return p.poster.Handle(pack, &lib.KeyRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	publishKey := &lib.PublishKey{}
+	if err := p.unmarshal(pack, publishKey); err != nil {
+		return err
+	}
+
+	// X25519 公钥固定为 32 字节
+	if len(publishKey.Key) != 32 {
+		return p.poster.Handle(pack, &lib.KeyRes{Code: lib.Err_Invalid_Key.Val()})
+	}
+
+	if err := p.storage.UpdatePublicKey(*accId, publishKey.Key, publishKey.Backup); err != nil {
+		return p.poster.Handle(pack, &lib.KeyRes{Code: lib.Err_Invalid_Key.Val()})
+	}
+
+	return p.poster.Handle(pack, &lib.KeyRes{Username: *accUN, Key: publishKey.Key, Backup: publishKey.Backup})
+}
+
+var _ biz_i = (*biz_publish_key_t)(nil)
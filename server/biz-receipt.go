@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理已读回执请求，标记消息为已读，并向消息发送者转发已读回执
+type biz_receipt_t struct {
+	biz_base_t
+}
+
+func initialReceipt(base biz_base_t) *biz_receipt_t {
+	return &biz_receipt_t{base}
+}
+
+func (r *biz_receipt_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := r.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	// 回执是非同步请求，客户端不等待响应。无效回执直接丢弃，不能返回 ERR packet，否则客户端会断开连接
+	if len(*accUN) == 0 {
+		slog.Warn("drop receipt", "sid", r.sid, "err", "not signed in")
+		return nil
+	}
+
+	receipt := &lib.Receipt{}
+	if err := lib.Unmarshal(pack.Data, receipt); err != nil {
+		slog.Warn("drop receipt", "sid", r.sid, "acc_id", *accId, "username", *accUN, "err", err)
+		return nil
+	}
+
+	// 客户端只能发送已读回执，已转发回执由服务器生成
+	if receipt.Status != lib.MsgStatus_READ {
+		return nil
+	}
+
+	msgList, err := r.storage.UpdateRead(receipt.Ids, *accUN)
+	if err != nil {
+		return err
+	}
+	if len(msgList) == 0 {
+		return nil
+	}
+
+	if err := sendReceipts(r.eventChan, lib.MsgStatus_READ, *accUN, msgList); err != nil {
+		return err
+	}
+
+	// 通知当前用户的所有设备这些消息已读，peer 为自己
+	ids := make([]int64, len(msgList))
+	for i := range msgList {
+		ids[i] = msgList[i].Id
+	}
+	bytes, err := lib.Marshal(&lib.Receipt{Status: lib.MsgStatus_READ, Peer: *accUN, Ids: ids})
+	if err != nil {
+		return err
+	}
+	r.eventChan <- &e_push_t{*accUN, &lib.Push{Kind: lib.PushKind_MSG_RECEIPT, Data: bytes}}
+	return nil
+}
+
+var _ biz_i = (*biz_receipt_t)(nil)
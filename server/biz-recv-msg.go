@@ -9,11 +9,12 @@ import (
 // 处理发送消息请求
 type biz_recv_msg_t struct {
 	biz_base_t
-	node *snowflake.Node
+	node     *snowflake.Node
+	webhooks *webhooks_t
 }
 
-func initialRecvMsg(base biz_base_t, node *snowflake.Node) *biz_recv_msg_t {
-	return &biz_recv_msg_t{base, node}
+func initialRecvMsg(base biz_base_t, node *snowflake.Node, webhooks *webhooks_t) *biz_recv_msg_t {
+	return &biz_recv_msg_t{base, node, webhooks}
 }
 
 func (rm *biz_recv_msg_t) do(req proto.Message, accId *uint64, accUN *string) error {
@@ -23,22 +24,80 @@ func (rm *biz_recv_msg_t) do(req proto.Message, accId *uint64, accUN *string) er
 	}
 
 	if len(*accUN) == 0 {
-		return rm.poster.Send(&lib.ErrRes{Code: lib.Err_Forbidden.Val()})
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_Forbidden.Val()})
 	}
 
 	msg := &lib.Msg{}
 	if err := lib.Unmarshal(pack.Data, msg); err != nil {
-		return rm.poster.Send(&lib.ErrRes{Code: lib.Err_Unmarshal.Val()})
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_Unmarshal.Val()})
 	}
 
-	return rm.storage.NewMsg(&Message{
+	message := &Message{
 		// 生成消息 ID
-		Id:   int64(rm.node.Generate()),
-		Kind: int32(lib.MsgKind_TEXT),
-		From: msg.From,
-		To:   msg.To,
-		Data: msg.Data,
-	})
+		Id:    int64(rm.node.Generate()),
+		Kind:  int32(msg.Kind),
+		From:  *accUN,
+		To:    msg.To,
+		Data:  msg.Data,
+		Group: msg.Group,
+	}
+
+	if msg.Group == 0 {
+		// 接收者不存在时不存储消息
+		if len(msg.To) == 0 {
+			return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_Acc_Not_Exist.Val()})
+		}
+		if _, err := rm.storage.GetAccountByUN(msg.To); err != nil {
+			return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_Acc_Not_Exist.Val()})
+		}
+
+		if err := rm.storage.NewMsg(message); err != nil {
+			return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_New_Msg.Val()})
+		}
+		metricMsgsStored.Inc()
+		// 存储后直接转发给接收者在线的 session，接收者离线时消息保存在存储中，等待上线后转发
+		rm.eventChan <- &e_msg_t{message.toLibMsg()}
+		// 同步给发送者的其他设备
+		rm.eventChan <- &e_sync_t{rm.sid, message.toLibMsg()}
+		// 异步通知外部系统
+		rm.webhooks.notify(message.toLibMsg())
+
+		// 向发送者返回消息 ID
+		return rm.poster.Handle(pack, &lib.MsgRes{Id: message.Id})
+	}
+
+	// 群消息按成员存储，忽略客户端填写的接收者
+	message.To = ""
+
+	// 群消息，只有群成员可以发送
+	if member, err := rm.storage.IsGroupMember(msg.Group, *accUN); err != nil {
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_New_Msg.Val()})
+	} else if !member {
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_Not_Group_Member.Val()})
+	}
+
+	members, err := rm.storage.GetGroupMembers(msg.Group)
+	if err != nil {
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_New_Msg.Val()})
+	}
+
+	if err := rm.storage.NewGroupMsg(message, members); err != nil {
+		return rm.poster.Handle(pack, &lib.MsgRes{Code: lib.Err_New_Msg.Val()})
+	}
+	metricMsgsStored.Inc()
+
+	for _, member := range members {
+		if member != *accUN {
+			m := *message
+			m.To = member
+			rm.eventChan <- &e_msg_t{m.toLibMsg()}
+		}
+	}
+	// 同步给发送者的其他设备
+	rm.eventChan <- &e_sync_t{rm.sid, message.toLibMsg()}
+	// 异步通知外部系统
+	rm.webhooks.notify(message.toLibMsg())
+	return rm.poster.Handle(pack, &lib.MsgRes{Id: message.Id})
 }
 
 var _ biz_i = (*biz_recv_msg_t)(nil)
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理撤销登录 session 请求，撤销后 token 失效，使用这些 session 登录的连接会被断开
+type biz_revoke_t struct {
+	biz_base_t
+}
+
+func initialRevoke(base biz_base_t) *biz_revoke_t {
+	return &biz_revoke_t{base}
+}
+
+func (r *biz_revoke_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := r.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return r.poster.Handle(pack, &lib.RevokeRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	revoke := &lib.Revoke{}
+	if err := r.unmarshal(pack, revoke); err != nil {
+		return err
+	}
+
+	ids := []uint64{revoke.Id}
+	if revoke.Others {
+		sessions, err := r.storage.GetSessions(*accId)
+		if err != nil {
+			return r.poster.Handle(pack, &lib.RevokeRes{Code: lib.Err_Get_Sessions.Val()})
+		}
+		ids = ids[:0]
+		for i := range sessions {
+			if sessions[i].Id != *r.tid {
+				ids = append(ids, sessions[i].Id)
+			}
+		}
+	}
+
+	revoked, err := r.storage.RevokeSessions(*accId, ids)
+	if err != nil {
+		return r.poster.Handle(pack, &lib.RevokeRes{Code: lib.Err_Get_Sessions.Val()})
+	}
+	if !revoke.Others && len(revoked) == 0 {
+		return r.poster.Handle(pack, &lib.RevokeRes{Code: lib.Err_Session_Not_Exist.Val()})
+	}
+
+	if len(revoked) > 0 {
+		r.eventChan <- &e_revoke_t{*accUN, revoked}
+	}
+	return r.poster.Handle(pack, &lib.RevokeRes{Ids: revoked})
+}
+
+var _ biz_i = (*biz_revoke_t)(nil)
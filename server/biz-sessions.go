@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 处理查询当前用户登录 session 列表请求
+type biz_sessions_t struct {
+	biz_base_t
+}
+
+func initialSessions(base biz_base_t) *biz_sessions_t {
+	return &biz_sessions_t{base}
+}
+
+func (s *biz_sessions_t) do(req proto.Message, accId *uint64, accUN *string) error {
+	pack, err := s.toPacket(req)
+	if err != nil {
+		return err
+	}
+
+	if len(*accUN) == 0 {
+		return s.poster.Handle(pack, &lib.SessionsRes{Code: lib.Err_Forbidden.Val()})
+	}
+
+	sessions, err := s.storage.GetSessions(*accId)
+	if err != nil {
+		return s.poster.Handle(pack, &lib.SessionsRes{Code: lib.Err_Get_Sessions.Val()})
+	}
+
+	sessionsRes := &lib.SessionsRes{Sessions: make([]*lib.Session, len(sessions))}
+	for i := range sessions {
+		sessionsRes.Sessions[i] = &lib.Session{
+			Id:        sessions[i].Id,
+			Created:   sessions[i].CreatedAt.Unix(),
+			Refreshed: sessions[i].UpdatedAt.Unix(),
+			Current:   sessions[i].Id == *s.tid,
+		}
+	}
+	return s.poster.Handle(pack, sessionsRes)
+}
+
+var _ biz_i = (*biz_sessions_t)(nil)
@@ -30,7 +30,7 @@ func (s *biz_signin_t) do(req proto.Message, accId *uint64, accUN *string) error
 
 	account, err := s.storage.GetAccountByUN(signin.Auth.Username)
 	if err != nil {
-		return s.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Acc_Not_Exist.Val()})
+		return s.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Acc_Not_Exist).Val()})
 	}
 
 	passhashAndBcrypt, err := base64.StdEncoding.DecodeString(account.PasshashAndBcrypt)
@@ -39,10 +39,10 @@ func (s *biz_signin_t) do(req proto.Message, accId *uint64, accUN *string) error
 	}
 
 	if err := bcrypt.CompareHashAndPassword(passhashAndBcrypt, signin.Auth.Passhash); err != nil {
-		return s.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Bcrypt_Compare.Val()})
+		return s.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Bcrypt_Compare).Val()})
 	}
 
-	return s.handleAuth(pack, account, accId, accUN)
+	return s.handleAuth(pack, account, nil, accId, accUN)
 }
 
 var _ biz_i = (*biz_signin_t)(nil)
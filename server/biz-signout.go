@@ -15,23 +15,19 @@ func initialSignout(base biz_base_t) *biz_signout_t {
 }
 
 func (s *biz_signout_t) do(req proto.Message, accId *uint64, accUN *string) error {
-	s.storage.UpdateOnline(*accId, false)
+	// 撤销当前登录 session，token 随之失效
+	if *s.tid > 0 {
+		if _, err := s.storage.RevokeSessions(*accId, []uint64{*s.tid}); err != nil {
+			return err
+		}
+	}
 
-	// 下线事件
+	// 下线事件，用户最后一个 session 下线时会更新在线状态并发送下线提醒
 	s.eventChan <- &e_offline_t{s.sid}
 
-	// 下线提醒
-	bytes, err := lib.Marshal(&lib.Online{Kind: lib.OnlineKind_OFF, Username: *accUN})
-	if err != nil {
-		return err
-	}
-	s.pushChan <- &lib.Push{
-		Kind: lib.PushKind_ONLINE,
-		Data: bytes,
-	}
-
 	*accId = 0
 	*accUN = ""
+	*s.tid = 0
 
 	return s.poster.Handle(req, &lib.SignoutRes{})
 }
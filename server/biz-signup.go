@@ -28,7 +28,7 @@ func (s *biz_signup_t) do(req proto.Message, accId *uint64, accUN *string) error
 		return err
 	}
 
-	passhashAndBcrypt, err := bcrypt.GenerateFromPassword(signup.Auth.Passhash, 14)
+	passhashAndBcrypt, err := bcrypt.GenerateFromPassword(signup.Auth.Passhash, conf.BcryptCost)
 	if err != nil {
 		return s.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Bcrypt_Gen.Val()})
 	}
@@ -36,12 +36,13 @@ func (s *biz_signup_t) do(req proto.Message, accId *uint64, accUN *string) error
 	account := &Account{
 		Username:          signup.Auth.Username,
 		PasshashAndBcrypt: base64.StdEncoding.EncodeToString(passhashAndBcrypt),
+		Bot:               signup.Bot,
 	}
 	if err := s.storage.NewAccount(account); err != nil {
 		return s.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Acc_Exist.Val()})
 	}
 
-	return s.handleAuth(pack, account, accId, accUN)
+	return s.handleAuth(pack, account, nil, accId, accUN)
 }
 
 var _ biz_i = (*biz_signup_t)(nil)
@@ -25,21 +25,35 @@ func (vt *biz_val_token_t) do(req proto.Message, accId *uint64, accUN *string) e
 		return err
 	}
 
-	id, expired, err := ParseToken(tokenReq.Token)
+	id, tid, serial, expired, err := ParseToken(tokenReq.Token)
 	if err != nil {
-		return vt.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Parse_Token.Val()})
+		return vt.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Parse_Token).Val()})
 	}
 
 	if expired {
-		return vt.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Token_Expired.Val()})
+		return vt.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Token_Expired).Val()})
+	}
+
+	// session 已撤销，或者 token 已经被刷新过
+	session, err := vt.storage.GetSession(tid)
+	if err != nil || session.AccountId != id {
+		return vt.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Token_Revoked).Val()})
+	}
+	// 宽限期内的上一个 token 不再刷新，返回当前序号的 token
+	if !inGrace(session, serial) {
+		if ok, err := vt.storage.RotateSession(session, serial); err != nil || !ok {
+			return vt.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Token_Revoked).Val()})
+		}
+		session.Serial = serial + 1
 	}
 
 	account, err := vt.storage.GetAccountById(id)
 	if err != nil {
-		return vt.poster.Handle(pack, &lib.TokenRes{Code: lib.Err_Acc_Not_Exist.Val()})
+		return vt.poster.Handle(pack, &lib.TokenRes{Code: authFailed(lib.Err_Acc_Not_Exist).Val()})
 	}
 
-	return vt.handleAuth(pack, account, accId, accUN)
+	// 验证成功后刷新 token，旧 token 在宽限期后失效
+	return vt.handleAuth(pack, account, session, accId, accUN)
 }
 
 var _ biz_i = (*biz_val_token_t)(nil)
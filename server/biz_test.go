@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+)
+
+// 使用临时密钥签发 token，测试结束后恢复
+func testKeys(t *testing.T) {
+	t.Helper()
+	t.Setenv("SECRET_KEY", "")
+	os.Unsetenv("SECRET_KEY")
+	keys, err := loadKeyStore(filepath.Join(t.TempDir(), "server.keys"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := tokenKeys
+	tokenKeys = keys
+	t.Cleanup(func() { tokenKeys = saved })
+}
+
+// biz 测试共用的 snowflake 节点，保证生成的消息 id 不重复
+var testNode = func() *snowflake.Node {
+	node, err := snowflake.NewNode(1)
+	lib.FatalNotNil(err)
+	return node
+}()
+
+// 使用 biz 处理请求 req，返回响应
+func doBiz(t *testing.T, kind lib.PackKind, base biz_base_t, req proto.Message, accId *uint64, accUN *string) proto.Message {
+	t.Helper()
+	data, err := lib.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poster := &api_poster_t{}
+	base.poster = poster
+	biz, err := kindToBiz(kind, base, testNode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := biz.do(&lib.Packet{Kind: kind, Data: data}, accId, accUN); err != nil {
+		t.Fatal(err)
+	}
+	return poster.res
+}
+
+// 验证 token，返回响应
+func valToken(t *testing.T, storage store_i, token []byte) *lib.TokenRes {
+	t.Helper()
+	var (
+		accId uint64
+		accUN string
+	)
+	return doBiz(t, lib.PackKind_TOKEN, initialAPIBase(nil, nil, nil, storage), &lib.Token{Token: token}, &accId, &accUN).(*lib.TokenRes)
+}
+
+// 返回 token 中的 session 序号
+func tokenSerial(t *testing.T, token []byte) uint64 {
+	t.Helper()
+	_, _, serial, _, err := ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serial
+}
+
+// 验证 token 后刷新序号，上一个 token 只在宽限期内有效
+func TestValTokenRotate(t *testing.T) {
+	testKeys(t)
+	storage := newMemoryStore()
+	account := &Account{Username: "alice"}
+	if err := storage.NewAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	session, err := storage.NewSession(account.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token0, err := GenerateToken(account.Id, session.Id, session.Serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := valToken(t, storage, token0)
+	if res.Code != 0 || res.Username != "alice" || tokenSerial(t, res.Token) != 1 {
+		t.Fatalf("first validation: %v", res)
+	}
+	token1 := res.Token
+
+	// 客户端没有收到刷新后的 token，使用上一个 token 重试时不再刷新
+	res = valToken(t, storage, token0)
+	if res.Code != 0 || tokenSerial(t, res.Token) != 1 {
+		t.Fatalf("retry within grace: %v", res)
+	}
+
+	res = valToken(t, storage, token1)
+	if res.Code != 0 || tokenSerial(t, res.Token) != 2 {
+		t.Fatalf("second validation: %v", res)
+	}
+	token2 := res.Token
+
+	// 更早的 token 已失效
+	if res = valToken(t, storage, token0); res.Code != lib.Err_Token_Revoked.Val() {
+		t.Errorf("stale token: %v", res)
+	}
+
+	// 宽限期后上一个 token 失效
+	storage.mu.Lock()
+	storage.sessions[session.Id].UpdatedAt = time.Now().Add(-TOKEN_GRACE)
+	storage.mu.Unlock()
+	if res = valToken(t, storage, token1); res.Code != lib.Err_Token_Revoked.Val() {
+		t.Errorf("previous token after grace: %v", res)
+	}
+	if res = valToken(t, storage, token2); res.Code != 0 {
+		t.Errorf("current token: %v", res)
+	}
+}
+
+// 撤销 session 后 token 失效，Others 撤销除当前 session 之外的所有 session
+func TestRevokeSessions(t *testing.T) {
+	testKeys(t)
+	storage := newMemoryStore()
+	account := &Account{Username: "alice"}
+	if err := storage.NewAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		sessions []*Session
+		tokens   [][]byte
+	)
+	for i := 0; i < 3; i++ {
+		session, err := storage.NewSession(account.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := GenerateToken(account.Id, session.Id, session.Serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions = append(sessions, session)
+		tokens = append(tokens, token)
+	}
+
+	eventChan := make(chan event_i, 1)
+	base := initialAPIBase(nil, eventChan, nil, storage)
+	*base.tid = sessions[0].Id
+	accId, accUN := account.Id, account.Username
+
+	// 未登录不能撤销
+	var anonId uint64
+	var anonUN string
+	if res := doBiz(t, lib.PackKind_REVOKE, base, &lib.Revoke{Id: sessions[1].Id}, &anonId, &anonUN).(*lib.RevokeRes); res.Code != lib.Err_Forbidden.Val() {
+		t.Errorf("anonymous revoke: %v", res)
+	}
+
+	res := doBiz(t, lib.PackKind_REVOKE, base, &lib.Revoke{Id: sessions[1].Id}, &accId, &accUN).(*lib.RevokeRes)
+	if res.Code != 0 || !reflect.DeepEqual(res.Ids, []uint64{sessions[1].Id}) {
+		t.Fatalf("revoke: %v", res)
+	}
+	// 通知 hub 断开使用该 session 登录的连接
+	if e, ok := (<-eventChan).(*e_revoke_t); !ok || e.username != "alice" || !reflect.DeepEqual(e.tids, res.Ids) {
+		t.Errorf("revoke event: %v", e)
+	}
+	if res := valToken(t, storage, tokens[1]); res.Code != lib.Err_Token_Revoked.Val() {
+		t.Errorf("revoked token: %v", res)
+	}
+	if res := doBiz(t, lib.PackKind_REVOKE, base, &lib.Revoke{Id: sessions[1].Id}, &accId, &accUN).(*lib.RevokeRes); res.Code != lib.Err_Session_Not_Exist.Val() {
+		t.Errorf("revoke twice: %v", res)
+	}
+
+	res = doBiz(t, lib.PackKind_REVOKE, base, &lib.Revoke{Others: true}, &accId, &accUN).(*lib.RevokeRes)
+	if res.Code != 0 || !reflect.DeepEqual(res.Ids, []uint64{sessions[2].Id}) {
+		t.Fatalf("revoke others: %v", res)
+	}
+	<-eventChan
+	if res := valToken(t, storage, tokens[2]); res.Code != lib.Err_Token_Revoked.Val() {
+		t.Errorf("revoked token: %v", res)
+	}
+	if res := valToken(t, storage, tokens[0]); res.Code != 0 {
+		t.Errorf("current session token: %v", res)
+	}
+}
+
+// 单聊消息的接收者必须存在，群消息忽略客户端填写的接收者
+func TestRecvMsg(t *testing.T) {
+	storage := newMemoryStore()
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if err := storage.NewAccount(&Account{Username: username}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventChan := make(chan event_i, 16)
+	base := initialAPIBase(nil, eventChan, nil, storage)
+	accId, accUN := uint64(1), "alice"
+
+	for _, to := range []string{"", "nobody"} {
+		res := doBiz(t, lib.PackKind_MSG, base, &lib.Msg{To: to, Data: []byte("hi")}, &accId, &accUN).(*lib.MsgRes)
+		if res.Code != lib.Err_Acc_Not_Exist.Val() {
+			t.Errorf("send to %q: %v", to, res)
+		}
+		if msgs, _ := storage.GetMsgList(to); len(msgs) > 0 {
+			t.Errorf("message to %q should not be stored", to)
+		}
+	}
+	if len(eventChan) > 0 {
+		t.Errorf("rejected messages should not be forwarded")
+	}
+
+	res := doBiz(t, lib.PackKind_MSG, base, &lib.Msg{To: "bob", Data: []byte("hi")}, &accId, &accUN).(*lib.MsgRes)
+	if res.Code != 0 || res.Id == 0 {
+		t.Fatalf("send to bob: %v", res)
+	}
+	if e := (<-eventChan).(*e_msg_t); e.msg.To != "bob" || e.msg.From != "alice" {
+		t.Errorf("forwarded %v", e.msg)
+	}
+	<-eventChan
+
+	group := &Group{Name: "dev", Owner: "alice"}
+	if err := storage.NewGroup(group, []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	res = doBiz(t, lib.PackKind_MSG, base, &lib.Msg{To: "carol", Group: group.Id, Data: []byte("hi")}, &accId, &accUN).(*lib.MsgRes)
+	if res.Code != 0 {
+		t.Fatalf("send to group: %v", res)
+	}
+	if e := (<-eventChan).(*e_msg_t); e.msg.To != "bob" {
+		t.Errorf("group message forwarded to %q", e.msg.To)
+	}
+	if e := (<-eventChan).(*e_sync_t); e.msg.To != "" {
+		t.Errorf("group sync copy To = %q, want empty", e.msg.To)
+	}
+	if msgs, _ := storage.GetMsgList("carol"); len(msgs) > 0 {
+		t.Error("non-member should not receive group message")
+	}
+
+	// 查询群成员失败时不能当作非群成员
+	flaky := &flaky_store_t{store_i: storage, err: errors.New("database is down")}
+	res = doBiz(t, lib.PackKind_MSG, initialAPIBase(nil, eventChan, nil, flaky), &lib.Msg{Group: group.Id, Data: []byte("hi")}, &accId, &accUN).(*lib.MsgRes)
+	if res.Code != lib.Err_New_Msg.Val() {
+		t.Errorf("send to group with store error: %v", res)
+	}
+}
+
+// 记录非同步发送的 packet
+type record_poster_t struct {
+	api_poster_t
+	sent []proto.Message
+}
+
+func (p *record_poster_t) Send(req proto.Message) error {
+	p.sent = append(p.sent, req)
+	return nil
+}
+
+// 无效回执直接丢弃，不向客户端返回响应或者 ERR packet
+func TestReceiptDropped(t *testing.T) {
+	poster := &record_poster_t{}
+	base := initialAPIBase(poster, make(chan event_i, 1), nil, newMemoryStore())
+	biz := initialReceipt(base)
+
+	var anonId uint64
+	var anonUN string
+	data, err := lib.Marshal(&lib.Receipt{Status: lib.MsgStatus_READ, Ids: []int64{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := biz.do(&lib.Packet{Kind: lib.PackKind_RECEIPT, Data: data}, &anonId, &anonUN); err != nil {
+		t.Fatal(err)
+	}
+
+	accId, accUN := uint64(1), "alice"
+	if err := biz.do(&lib.Packet{Kind: lib.PackKind_RECEIPT, Data: []byte{0xff}}, &accId, &accUN); err != nil {
+		t.Fatal(err)
+	}
+	if poster.res != nil || len(poster.sent) > 0 {
+		t.Errorf("invalid receipts answered with %v %v", poster.res, poster.sent)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 握手时接受方发送的随机 nonce 长度
+const CLUSTER_NONCE_SIZE = 32
+
+// 到其他节点的出站连接已建立或已断开。c 为 nil 时表示连接已断开，hub 关闭 kick 时表示需要断开连接
+//
+// 连接建立后 hub 先通过 snapshot 发送当前节点所有在线用户，之后的事件通过 c 发送
+type e_peer_t struct {
+	node     int64
+	c        chan<- *lib.ClusterEvent
+	kick     chan struct{}
+	snapshot chan<- []*lib.ClusterEvent
+}
+
+// 从其他节点收到的事件，ev 为 nil 时表示入站连接已断开。conn 为入站连接 id，节点重新连接后旧连接上的事件被忽略
+type e_remote_t struct {
+	node int64
+	conn uint64
+	ev   *lib.ClusterEvent
+}
+
+// 集群节点。节点启动时在共享存储中注册，分配唯一的节点 id，并定时刷新心跳
+//
+// 每个节点都与其他所有节点建立一个出站连接，只用于发送事件，接收事件使用其他节点的出站连接。节点之间转发上下线、新消息和 push，hub 根据上下线事件维护每个用户所在的节点
+type cluster_t struct {
+	node      *Node
+	storage   store_i
+	eventChan chan<- event_i
+	ln        net.Listener
+	secret    []byte
+	ttl       time.Duration
+
+	// 入站连接 id
+	connId uint64
+	// 关闭时停止注册、连接和接收
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// 当前所有入站连接，关闭时断开
+	inbound map[net.Conn]bool
+}
+
+// 监听 c.ClusterAddr，注册节点，并开始连接其他节点
+func joinCluster(c *config_t, storage store_i, eventChan chan<- event_i) (*cluster_t, error) {
+	ln, err := net.Listen("tcp", c.ClusterAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	advertise := c.ClusterAdvertise
+	if len(advertise) == 0 {
+		advertise = ln.Addr().String()
+	}
+	node, err := storage.RegisterNode(advertise, c.ClusterTTL)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	cl := &cluster_t{
+		node:      node,
+		storage:   storage,
+		eventChan: eventChan,
+		ln:        ln,
+		secret:    []byte(c.ClusterSecret),
+		ttl:       c.ClusterTTL,
+		quit:      make(chan struct{}),
+		inbound:   make(map[net.Conn]bool),
+	}
+	cl.wg.Add(2)
+	go cl.accept()
+	go cl.refresh()
+	return cl, nil
+}
+
+// 节点 id
+func (cl *cluster_t) id() int64 {
+	return cl.node.Id
+}
+
+// 离开集群: 断开所有连接并注销节点，其他节点会把当前节点上的用户设置为离线
+func (cl *cluster_t) leave() {
+	close(cl.quit)
+	cl.ln.Close()
+	cl.mu.Lock()
+	for conn := range cl.inbound {
+		conn.Close()
+	}
+	cl.mu.Unlock()
+	cl.wg.Wait()
+	if err := cl.storage.RemoveNode(cl.node); err != nil {
+		slog.Error("remove cluster node", "node", cl.node.Id, "err", err)
+	}
+}
+
+// 节点之间转发的 packet 包含一个完整的客户端 packet
+func clusterFrameSize() uint32 {
+	return uint32(conf.MaxFrameSize) * 2
+}
+
+// 读取一个集群事件
+func readClusterEvent(r *lib.FrameReader) (*lib.ClusterEvent, error) {
+	pack := &lib.Packet{}
+	if err := r.ReadPack(pack); err != nil {
+		return nil, err
+	}
+	if pack.Kind != lib.PackKind_CLUSTER {
+		return nil, errors.New("invalid kind of cluster packet")
+	}
+	ev := &lib.ClusterEvent{}
+	if err := lib.Unmarshal(pack.Data, ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// 发送一个集群事件
+func writeClusterEvent(conn net.Conn, w *lib.FrameWriter, ev *lib.ClusterEvent) error {
+	bytes, err := lib.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(conf.WriteTimeout))
+	return w.WritePack(&lib.Packet{Kind: lib.PackKind_CLUSTER, Data: bytes})
+}
+
+// 使用集群密钥计算 HMAC-SHA256(nonce | node)。nonce 由接受连接的节点为每个连接随机生成，截获的 HELLO 无法在其他连接上重放
+func clusterMAC(secret, nonce []byte, node int64) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(nonce)
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(node)))
+	return h.Sum(nil)
+}
+
+// 接受其他节点的连接
+func (cl *cluster_t) accept() {
+	defer cl.wg.Done()
+	for {
+		conn, err := cl.ln.Accept()
+		if err != nil {
+			select {
+			case <-cl.quit:
+				return
+			default:
+			}
+			slog.Error("cluster accept", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		cl.mu.Lock()
+		cl.inbound[conn] = true
+		cl.mu.Unlock()
+
+		cl.wg.Add(1)
+		go cl.serve(conn)
+	}
+}
+
+// 读取其他节点发送的事件并转发给 hub。连接建立后先发送随机 nonce，对方必须回复使用集群密钥计算的 HELLO，之后每 ttl/3 发送一次 ping，超过 ttl 没有收到事件时断开连接
+func (cl *cluster_t) serve(conn net.Conn) {
+	defer cl.wg.Done()
+	defer func() {
+		cl.mu.Lock()
+		delete(cl.inbound, conn)
+		cl.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := lib.NewFrameReader(conn, clusterFrameSize())
+	read := func() (*lib.ClusterEvent, error) {
+		conn.SetReadDeadline(time.Now().Add(cl.ttl))
+		return readClusterEvent(r)
+	}
+
+	nonce := make([]byte, CLUSTER_NONCE_SIZE)
+	_, err := rand.Read(nonce)
+	if err == nil {
+		err = writeClusterEvent(conn, lib.NewFrameWriter(conn, clusterFrameSize()), &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_CHALLENGE, Node: cl.node.Id, Nonce: nonce})
+	}
+	if err != nil {
+		slog.Warn("cluster challenge", "remote", conn.RemoteAddr().String(), "err", err)
+		return
+	}
+
+	hello, err := read()
+	if err != nil {
+		slog.Warn("cluster hello", "remote", conn.RemoteAddr().String(), "err", err)
+		return
+	}
+	if hello.Kind != lib.ClusterKind_CLUSTER_HELLO || !hmac.Equal(hello.Mac, clusterMAC(cl.secret, nonce, hello.Node)) {
+		slog.Warn("cluster connection rejected", "remote", conn.RemoteAddr().String(), "node", hello.Node)
+		return
+	}
+
+	id := atomic.AddUint64(&cl.connId, 1)
+	cl.eventChan <- &e_remote_t{hello.Node, id, hello}
+	defer func() {
+		cl.eventChan <- &e_remote_t{hello.Node, id, nil}
+	}()
+
+	for {
+		ev, err := read()
+		if err != nil {
+			select {
+			case <-cl.quit:
+			default:
+				slog.Warn("cluster inbound closed", "node", hello.Node, "remote", conn.RemoteAddr().String(), "err", err)
+			}
+			return
+		}
+		if ev.Kind != lib.ClusterKind_CLUSTER_PING {
+			cl.eventChan <- &e_remote_t{hello.Node, id, ev}
+		}
+	}
+}
+
+// 每 ttl/3 刷新一次心跳，并根据已注册的节点建立或断开出站连接
+func (cl *cluster_t) refresh() {
+	defer cl.wg.Done()
+
+	// 节点 id -> 出站连接关闭 channel
+	links := make(map[int64]chan struct{})
+	// 节点 id -> 地址，节点 id 被新节点接管后重新连接
+	addrs := make(map[int64]string)
+	defer func() {
+		for _, stop := range links {
+			close(stop)
+		}
+	}()
+
+	ticker := time.NewTicker(cl.ttl / 3)
+	defer ticker.Stop()
+	for {
+		// 节点 id 可能已经被其他节点接管，继续运行会产生重复的消息 id
+		lib.FatalNotNil(cl.heartbeat())
+
+		nodes, err := cl.storage.GetNodes(cl.ttl)
+		if err != nil {
+			slog.Warn("get cluster nodes", "err", err)
+		} else {
+			alive := make(map[int64]bool)
+			for _, node := range nodes {
+				if node.Id == cl.node.Id {
+					continue
+				}
+				alive[node.Id] = true
+				if stop, found := links[node.Id]; found {
+					if addrs[node.Id] == node.Addr {
+						continue
+					}
+					close(stop)
+				}
+
+				stop := make(chan struct{})
+				links[node.Id], addrs[node.Id] = stop, node.Addr
+				cl.wg.Add(1)
+				go cl.link(node, stop)
+			}
+			for id, stop := range links {
+				if !alive[id] {
+					close(stop)
+					delete(links, id)
+					delete(addrs, id)
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-cl.quit:
+			return
+		}
+	}
+}
+
+// 刷新心跳。存储暂时不可用时等待下次刷新，只有节点 id 已经被接管或者超过 ttl 没有刷新成功时才返回 error，此时其他节点可以接管当前节点 id
+func (cl *cluster_t) heartbeat() error {
+	err := cl.storage.HeartbeatNode(cl.node)
+	if err == nil || errors.Is(err, errNodeLost) {
+		return err
+	}
+	if time.Since(cl.node.Heartbeat) >= cl.ttl {
+		return fmt.Errorf("cluster heartbeat failed for %v: %w", cl.ttl, err)
+	}
+	slog.Warn("cluster heartbeat failed", "node", cl.node.Id, "err", err)
+	return nil
+}
+
+// 维护到 node 的出站连接，断开后按照指数回退重新连接，直到 stop 关闭
+func (cl *cluster_t) link(node Node, stop <-chan struct{}) {
+	defer cl.wg.Done()
+	for i := 0; ; i++ {
+		if i > 0 {
+			backoff := time.Duration(math.Min(float64(100*time.Millisecond)*math.Pow(2, float64(i-1)), float64(cl.ttl)))
+			select {
+			case <-time.After(backoff):
+			case <-stop:
+				return
+			}
+		}
+
+		err := cl.dial(node, stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		slog.Warn("cluster link closed", "node", node.Id, "addr", node.Addr, "err", err)
+	}
+}
+
+// 连接 node 并发送 HELLO，然后把 hub 转发的事件发送到 node，直到连接出错或 stop 关闭
+func (cl *cluster_t) dial(node Node, stop <-chan struct{}) error {
+	conn, err := net.DialTimeout("tcp", node.Addr, cl.ttl)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w := lib.NewFrameWriter(conn, clusterFrameSize())
+	send := func(ev *lib.ClusterEvent) error {
+		return writeClusterEvent(conn, w, ev)
+	}
+
+	// 使用对方的 nonce 计算 HELLO，集群密钥不在网络上传输
+	conn.SetReadDeadline(time.Now().Add(cl.ttl))
+	challenge, err := readClusterEvent(lib.NewFrameReader(conn, clusterFrameSize()))
+	if err != nil {
+		return err
+	}
+	if challenge.Kind != lib.ClusterKind_CLUSTER_CHALLENGE || len(challenge.Nonce) != CLUSTER_NONCE_SIZE {
+		return errors.New("invalid cluster challenge")
+	}
+	if err := send(&lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_HELLO, Node: cl.node.Id, Mac: clusterMAC(cl.secret, challenge.Nonce, cl.node.Id)}); err != nil {
+		return err
+	}
+
+	c := make(chan *lib.ClusterEvent, conf.EventBuffer)
+	kick := make(chan struct{})
+	snapshot := make(chan []*lib.ClusterEvent, 1)
+	cl.eventChan <- &e_peer_t{node.Id, c, kick, snapshot}
+	defer func() {
+		cl.eventChan <- &e_peer_t{node: node.Id, kick: kick}
+	}()
+
+	select {
+	case evs := <-snapshot:
+		for _, ev := range evs {
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	case <-stop:
+		return nil
+	}
+
+	ping := time.NewTicker(cl.ttl / 3)
+	defer ping.Stop()
+	for {
+		select {
+		case ev := <-c:
+			if err := send(ev); err != nil {
+				return err
+			}
+		case <-ping.C:
+			if err := send(&lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_PING}); err != nil {
+				return err
+			}
+		case <-kick:
+			return errors.New("cluster send queue full")
+		case <-stop:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+)
+
+const testClusterSecret = "cluster-secret"
+
+// 在当前进程中启动一个集群节点，所有节点共用 storage
+func startNode(t *testing.T, storage store_i) (chan<- event_i, *cluster_t) {
+	c := defaultConfig()
+	c.ClusterAddr = "127.0.0.1:0"
+	c.ClusterSecret = testClusterSecret
+	c.ClusterTTL = 300 * time.Millisecond
+
+	eventChan := make(chan event_i, conf.EventBuffer)
+	pushChan := make(chan *lib.Push, conf.EventBuffer)
+	go handlePush(eventChan, pushChan, storage)
+
+	cluster, err := joinCluster(c, storage, eventChan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snowflake.NewNode(cluster.id()); err != nil {
+		t.Fatal(err)
+	}
+	return eventChan, cluster
+}
+
+// 读取客户端收到的所有 packet
+func readPacks(client net.Conn) <-chan *lib.Packet {
+	packs := make(chan *lib.Packet, 1024)
+	go func() {
+		scanner := bufio.NewScanner(client)
+		scanner.Split(lib.SplitFunc)
+		for scanner.Scan() {
+			pack := &lib.Packet{}
+			if lib.Unmarshal(scanner.Bytes(), pack) == nil {
+				packs <- pack
+			}
+		}
+		close(packs)
+	}()
+	return packs
+}
+
+// 等待收到满足 match 的 packet，忽略其他 packet
+func expectPack(t *testing.T, packs <-chan *lib.Packet, desc string, match func(pack *lib.Packet) bool) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case pack, ok := <-packs:
+			if !ok {
+				t.Fatalf("%s: connection closed", desc)
+			}
+			if match(pack) {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("%s: timeout", desc)
+		}
+	}
+}
+
+// 节点之间的路由是异步建立的，每隔 100ms 重新执行一次 send，直到收到满足 match 的 packet
+func expectEventually(t *testing.T, packs <-chan *lib.Packet, desc string, send func(), match func(pack *lib.Packet) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		send()
+		retry := time.After(100 * time.Millisecond)
+	wait:
+		for {
+			select {
+			case pack, ok := <-packs:
+				if !ok {
+					t.Fatalf("%s: connection closed", desc)
+				}
+				if match(pack) {
+					return
+				}
+			case <-retry:
+				break wait
+			}
+		}
+	}
+	t.Fatalf("%s: timeout", desc)
+}
+
+// 匹配上下线提醒
+func isOnline(kind lib.OnlineKind, username string) func(pack *lib.Packet) bool {
+	return func(pack *lib.Packet) bool {
+		push := &lib.Push{}
+		online := &lib.Online{}
+		return pack.Kind == lib.PackKind_PUSH &&
+			lib.Unmarshal(pack.Data, push) == nil && push.Kind == lib.PushKind_ONLINE &&
+			lib.Unmarshal(push.Data, online) == nil && online.Kind == kind && online.Username == username
+	}
+}
+
+// 匹配新消息
+func isMsg(id int64) func(pack *lib.Packet) bool {
+	return func(pack *lib.Packet) bool {
+		msg := &lib.Msg{}
+		return pack.Kind == lib.PackKind_MSG && lib.Unmarshal(pack.Data, msg) == nil && msg.Id == id
+	}
+}
+
+// 在一个进程中运行 3 个节点: alice 在 a 上，bob 在 b 和 c 上，carol 在 c 上。消息、上下线提醒、push 和撤销 session 都能跨节点转发，节点离开后其他节点把该节点上的用户设置为离线
+func TestCluster(t *testing.T) {
+	storage := newMemoryStore()
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if err := storage.NewAccount(&Account{Username: username}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, clusterA := startNode(t, storage)
+	defer clusterA.leave()
+	b, clusterB := startNode(t, storage)
+	defer clusterB.leave()
+	c, clusterC := startNode(t, storage)
+
+	ids := map[int64]bool{clusterA.id(): true, clusterB.id(): true, clusterC.id(): true}
+	if len(ids) != 3 {
+		t.Fatalf("node ids not unique: %d, %d, %d", clusterA.id(), clusterB.id(), clusterC.id())
+	}
+
+	// newSession 使用 sid 作为帐号 id
+	alice, aliceClient := newSession(1, "alice", a, storage)
+	defer alice.Close()
+	aliceRecv := readPacks(aliceClient)
+	bobB, bobBClient := newSession(2, "bob", b, storage)
+	defer bobB.Close()
+	bobBRecv := readPacks(bobBClient)
+	bobC, bobCClient := newSession(2, "bob", c, storage)
+	defer bobC.Close()
+	bobCRecv := readPacks(bobCClient)
+	carol, carolClient := newSession(3, "carol", c, storage)
+	defer carol.Close()
+	go readPacks(carolClient)
+
+	// 来自 b 和 c 的上线提醒没有先后顺序
+	seen := make(map[string]bool)
+	expectPack(t, aliceRecv, "alice sees bob and carol online", func(pack *lib.Packet) bool {
+		for _, username := range []string{"bob", "carol"} {
+			if isOnline(lib.OnlineKind_ON, username)(pack) {
+				seen[username] = true
+			}
+		}
+		return len(seen) == 2
+	})
+	expectPack(t, bobBRecv, "bob sees alice online", isOnline(lib.OnlineKind_ON, "alice"))
+
+	// a 上发送给 bob 的消息转发到 b 和 c 上的所有 session
+	msg := func() { a <- &e_msg_t{&lib.Msg{Id: 1, From: "alice", To: "bob", Data: []byte("hello")}} }
+	expectEventually(t, bobBRecv, "bob on b receives msg", msg, isMsg(1))
+	expectEventually(t, bobCRecv, "bob on c receives msg", msg, isMsg(1))
+
+	// bob 在 b 上发送的消息同步到 c 上的 session
+	sync := func() { b <- &e_sync_t{2, &lib.Msg{Id: 2, From: "bob", To: "alice", Data: []byte("hi")}} }
+	expectEventually(t, bobCRecv, "bob on c receives sync", sync, isMsg(2))
+
+	// c 上产生的回执 push 转发给 a 上的 alice
+	receipt := func() {
+		lib.FatalNotNil(sendReceipts(c, lib.MsgStatus_DELIVERED, "bob", []Message{{Id: 1, From: "alice"}}))
+	}
+	expectEventually(t, aliceRecv, "alice receives receipt", receipt, func(pack *lib.Packet) bool {
+		push := &lib.Push{}
+		return pack.Kind == lib.PackKind_PUSH && lib.Unmarshal(pack.Data, push) == nil && push.Kind == lib.PushKind_MSG_RECEIPT
+	})
+
+	// c 离开集群后 carol 下线，bob 仍然在 b 上在线
+	clusterC.leave()
+	expectPack(t, aliceRecv, "alice sees carol offline", func(pack *lib.Packet) bool {
+		if isOnline(lib.OnlineKind_OFF, "bob")(pack) {
+			t.Error("bob should still be online on b")
+		}
+		return isOnline(lib.OnlineKind_OFF, "carol")(pack)
+	})
+	if account, _ := storage.GetAccountByUN("carol"); account.Online {
+		t.Error("carol should be offline in storage")
+	}
+	if account, _ := storage.GetAccountByUN("bob"); !account.Online {
+		t.Error("bob should be online in storage")
+	}
+	if nodes, _ := storage.GetNodes(time.Minute); len(nodes) != 2 {
+		t.Errorf("nodes = %v, want 2", nodes)
+	}
+
+	// a 上撤销 bob 的 session，断开 b 上的连接
+	a <- &e_revoke_t{"bob", []uint64{0}}
+	expectPack(t, bobBRecv, "bob on b revoked", func(pack *lib.Packet) bool {
+		errRes := &lib.ErrRes{}
+		return pack.Kind == lib.PackKind_ERR && lib.Unmarshal(pack.Data, errRes) == nil && errRes.Code == lib.Err_Token_Revoked.Val()
+	})
+}
+
+// 集群密钥不在网络上传输，使用错误密钥计算 HELLO 的节点连接被拒绝
+func TestClusterSecret(t *testing.T) {
+	_, cluster := startNode(t, newMemoryStore())
+	defer cluster.leave()
+
+	conn, err := net.Dial("tcp", cluster.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	challenge, err := readClusterEvent(lib.NewFrameReader(conn, clusterFrameSize()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Kind != lib.ClusterKind_CLUSTER_CHALLENGE || len(challenge.Nonce) != CLUSTER_NONCE_SIZE {
+		t.Fatalf("challenge = %v", challenge)
+	}
+	hello := &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_HELLO, Node: 2, Mac: clusterMAC([]byte("wrong"), challenge.Nonce, 2)}
+	if err := writeClusterEvent(conn, lib.NewFrameWriter(conn, clusterFrameSize()), hello); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("connection with wrong secret should be closed")
+	} else if err, ok := err.(net.Error); ok && err.Timeout() {
+		t.Fatal("connection with wrong secret was not closed")
+	}
+}
+
+// 心跳时存储不可用
+type flaky_store_t struct {
+	store_i
+	err error
+}
+
+func (s *flaky_store_t) HeartbeatNode(node *Node) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.store_i.HeartbeatNode(node)
+}
+
+func (s *flaky_store_t) IsGroupMember(id uint64, username string) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	return s.store_i.IsGroupMember(id, username)
+}
+
+// 存储暂时不可用时继续运行，超过 ttl 没有刷新成功或者节点 id 被接管时退出
+func TestClusterHeartbeat(t *testing.T) {
+	storage := &flaky_store_t{store_i: newMemoryStore()}
+	node, err := storage.RegisterNode("127.0.0.1:1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &cluster_t{node: node, storage: storage, ttl: time.Minute}
+
+	storage.err = errors.New("database is down")
+	if err := cl.heartbeat(); err != nil {
+		t.Errorf("transient failure should not fence: %v", err)
+	}
+	node.Heartbeat = time.Now().Add(-time.Minute)
+	if err := cl.heartbeat(); err == nil {
+		t.Error("node should fence after ttl without heartbeat")
+	}
+
+	storage.err = nil
+	if err := cl.heartbeat(); err != nil {
+		t.Errorf("heartbeat after recovery: %v", err)
+	}
+	lost := &Node{Id: node.Id, Addr: "127.0.0.1:2", Heartbeat: time.Now()}
+	if err := (&cluster_t{node: lost, storage: storage, ttl: time.Minute}).heartbeat(); !errors.Is(err, errNodeLost) {
+		t.Errorf("err = %v, want errNodeLost", err)
+	}
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// 服务器配置。优先级从低到高依次为: 默认值、配置文件、环境变量、命令行参数
+type config_t struct {
+	// 监听地址
+	Addr string `yaml:"addr"`
+	// 存储类型: sqlite、postgres 或 memory
+	Store string `yaml:"store"`
+	// sqlite 数据库文件路径
+	DBPath string `yaml:"db_path"`
+	// postgres 连接串
+	DBDSN string `yaml:"db_dsn"`
+	// token 密钥文件路径
+	KeysPath string `yaml:"keys_path"`
+	// snowflake 节点 id，同时运行多个实例时必须各不相同。集群模式下由节点注册分配，忽略该配置
+	NodeId int64 `yaml:"node_id"`
+	// bcrypt 计算强度
+	BcryptCost int `yaml:"bcrypt_cost"`
+	// token 有效期
+	TokenTTL time.Duration `yaml:"token_ttl"`
+	// 每个连接的发送队列长度
+	SessionBuffer int `yaml:"session_buffer"`
+	// 全局事件和 push 队列长度
+	EventBuffer int `yaml:"event_buffer"`
+	// 证书和私钥文件路径，配置后使用 tls
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// 开发模式，未配置证书时自动生成自签名证书
+	TLSDev bool `yaml:"tls_dev"`
+	// 自签名证书包含的域名或 IP
+	TLSHosts []string `yaml:"tls_hosts"`
+	// 关闭服务器时等待连接发送完待发送数据的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// 关闭服务器时建议客户端重新连接的等待时间
+	RetryAfter time.Duration `yaml:"retry_after"`
+	// 超过该时间没有收到客户端任何 packet (包括 ping) 时断开连接
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout"`
+	// 向客户端写入一个 packet 的最长时间
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// 慢速连接发送队列已满时的处理策略: disconnect 或 drop
+	SlowConsumer string `yaml:"slow_consumer"`
+	// 支持的最低客户端协议版本，大于 0 时拒绝未握手的旧版本客户端
+	MinVersion int `yaml:"min_version"`
+	// 收发 packet 的最大长度 (字节)，客户端发送超过该长度的 packet 时断开连接
+	MaxFrameSize int `yaml:"max_frame_size"`
+	// websocket 监听地址，为空时不启用。连接地址为 ws://ws_addr/ws，配置了证书时为 wss
+	WSAddr string `yaml:"ws_addr"`
+	// 允许的 websocket 请求来源，为空时只允许同源请求，* 允许所有来源
+	WSOrigins []string `yaml:"ws_origins"`
+	// HTTP API 监听地址，为空时不启用
+	APIAddr string `yaml:"api_addr"`
+	// prometheus 指标监听地址，为空时不启用。指标地址为 http://metrics_addr/metrics
+	MetricsAddr string `yaml:"metrics_addr"`
+	// 日志级别: debug、info、warn 或 error
+	LogLevel string `yaml:"log_level"`
+	// 日志格式: json 或 text
+	LogFormat string `yaml:"log_format"`
+	// 采样输出 packet 元数据的比例 (0-1)，不会输出消息内容。大于 0 时 log_level 必须为 debug
+	DebugSampleRate float64 `yaml:"debug_sample_rate"`
+	// 管理接口监听地址，为空时不启用。提供 /healthz、/readyz、/sessions 和 /debug/pprof
+	AdminAddr string `yaml:"admin_addr"`
+	// 消息 webhook，只能在配置文件中设置
+	Webhooks []webhook_config_t `yaml:"webhooks"`
+	// webhook 请求超时时间
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+	// webhook 投递失败后的最多重试次数
+	WebhookRetries int `yaml:"webhook_retries"`
+	// webhook 死信日志文件路径，重试后仍然失败的事件追加写入该文件
+	WebhookDeadLetter string `yaml:"webhook_dead_letter"`
+	// 集群内部通信监听地址，为空时以单节点模式运行。所有节点必须使用同一个 postgres 存储
+	ClusterAddr string `yaml:"cluster_addr"`
+	// 其他节点连接当前节点使用的地址，为空时使用 cluster_addr
+	ClusterAdvertise string `yaml:"cluster_advertise"`
+	// 集群共享密钥，节点之间建立连接时校验
+	ClusterSecret string `yaml:"cluster_secret"`
+	// 节点心跳超时时间，超过该时间没有心跳的节点被认为已下线
+	ClusterTTL time.Duration `yaml:"cluster_ttl"`
+}
+
+// 慢速连接处理策略
+const (
+	// 丢弃 push 或消息，并断开连接。客户端重新连接后会收到未转发的消息
+	SLOW_CONSUMER_DISCONNECT = "disconnect"
+	// 只丢弃 push 或消息，未转发的消息在客户端下次登录时转发
+	SLOW_CONSUMER_DROP = "drop"
+)
+
+// 输出配置时替换密码和密钥
+const REDACTED = "***"
+
+// 当前配置，启动时由 main 加载
+var conf = defaultConfig()
+
+func defaultConfig() *config_t {
+	return &config_t{
+		Addr:              ":8888",
+		Store:             STORE_SQLITE,
+		DBPath:            filepath.Join(lib.WorkDir, "server.db"),
+		KeysPath:          filepath.Join(lib.WorkDir, "server.keys"),
+		NodeId:            1,
+		BcryptCost:        14,
+		TokenTTL:          30 * 24 * time.Hour,
+		SessionBuffer:     1024,
+		EventBuffer:       1024,
+		TLSHosts:          []string{"localhost", "127.0.0.1"},
+		ShutdownTimeout:   10 * time.Second,
+		RetryAfter:        5 * time.Second,
+		HeartbeatTimeout:  60 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		SlowConsumer:      SLOW_CONSUMER_DISCONNECT,
+		MaxFrameSize:      int(lib.DEFAULT_MAX_FRAME_SIZE),
+		WebhookTimeout:    5 * time.Second,
+		WebhookRetries:    5,
+		WebhookDeadLetter: filepath.Join(lib.WorkDir, "webhook-dead-letter.log"),
+		ClusterTTL:        10 * time.Second,
+		LogLevel:          "info",
+		LogFormat:         LOG_FORMAT_JSON,
+	}
+}
+
+// 命令行参数，解析结果直接写入 c
+func (c *config_t) flagSet(args *cli_args_t) *flag.FlagSet {
+	fs := flag.NewFlagSet("gochat-server", flag.ContinueOnError)
+	fs.StringVar(&args.config, "config", args.config, "配置文件路径 (yaml)，也可以通过 CONFIG 环境变量设置")
+	fs.BoolVar(&args.printConfig, "print-config", false, "输出最终生效的配置后退出")
+	fs.BoolVar(&args.rotateKey, "rotate-key", false, "生成新版本 token 密钥后退出，之前签发的 token 仍然有效")
+
+	fs.StringVar(&c.Addr, "addr", c.Addr, "监听地址 (SVR_ADDR)")
+	fs.StringVar(&c.Store, "store", c.Store, "存储类型: sqlite、postgres 或 memory (STORE)")
+	fs.StringVar(&c.DBPath, "db", c.DBPath, "sqlite 数据库文件路径 (DB_PATH)")
+	fs.StringVar(&c.DBDSN, "db-dsn", c.DBDSN, "postgres 连接串 (DB_DSN)")
+	fs.StringVar(&c.KeysPath, "keys", c.KeysPath, "token 密钥文件路径 (KEYS_PATH)")
+	fs.Int64Var(&c.NodeId, "node-id", c.NodeId, "snowflake 节点 id (NODE_ID)")
+	fs.IntVar(&c.BcryptCost, "bcrypt-cost", c.BcryptCost, "bcrypt 计算强度 (BCRYPT_COST)")
+	fs.DurationVar(&c.TokenTTL, "token-ttl", c.TokenTTL, "token 有效期 (TOKEN_TTL)")
+	fs.IntVar(&c.SessionBuffer, "session-buffer", c.SessionBuffer, "每个连接的发送队列长度 (SESSION_BUFFER)")
+	fs.IntVar(&c.EventBuffer, "event-buffer", c.EventBuffer, "全局事件队列长度 (EVENT_BUFFER)")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "tls 证书文件路径 (TLS_CERT)")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "tls 私钥文件路径 (TLS_KEY)")
+	fs.BoolVar(&c.TLSDev, "tls-dev", c.TLSDev, "自动生成自签名证书 (TLS_DEV)")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "关闭服务器时等待连接发送完数据的最长时间 (SHUTDOWN_TIMEOUT)")
+	fs.DurationVar(&c.RetryAfter, "retry-after", c.RetryAfter, "关闭服务器时建议客户端重新连接的等待时间 (RETRY_AFTER)")
+	fs.DurationVar(&c.HeartbeatTimeout, "heartbeat-timeout", c.HeartbeatTimeout, "超过该时间没有收到客户端 packet 时断开连接 (HEARTBEAT_TIMEOUT)")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "向客户端写入一个 packet 的最长时间 (WRITE_TIMEOUT)")
+	fs.StringVar(&c.SlowConsumer, "slow-consumer", c.SlowConsumer, "慢速连接发送队列已满时的处理策略: disconnect 或 drop (SLOW_CONSUMER)")
+	fs.IntVar(&c.MinVersion, "min-version", c.MinVersion, "支持的最低客户端协议版本 (MIN_VERSION)")
+	fs.IntVar(&c.MaxFrameSize, "max-frame-size", c.MaxFrameSize, "收发 packet 的最大长度 (MAX_FRAME_SIZE)")
+	fs.StringVar(&c.WSAddr, "ws-addr", c.WSAddr, "websocket 监听地址，为空时不启用 (WS_ADDR)")
+	fs.StringVar(&c.APIAddr, "api-addr", c.APIAddr, "HTTP API 监听地址，为空时不启用 (API_ADDR)")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "prometheus 指标监听地址，为空时不启用 (METRICS_ADDR)")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "日志级别: debug、info、warn 或 error (LOG_LEVEL)")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "日志格式: json 或 text (LOG_FORMAT)")
+	fs.Float64Var(&c.DebugSampleRate, "debug-sample-rate", c.DebugSampleRate, "采样输出 packet 元数据的比例 (0-1)，需要 debug 日志级别 (DEBUG_SAMPLE_RATE)")
+	fs.StringVar(&c.AdminAddr, "admin-addr", c.AdminAddr, "管理接口监听地址，为空时不启用 (ADMIN_ADDR)")
+	fs.DurationVar(&c.WebhookTimeout, "webhook-timeout", c.WebhookTimeout, "webhook 请求超时时间 (WEBHOOK_TIMEOUT)")
+	fs.IntVar(&c.WebhookRetries, "webhook-retries", c.WebhookRetries, "webhook 投递失败后的最多重试次数 (WEBHOOK_RETRIES)")
+	fs.StringVar(&c.WebhookDeadLetter, "webhook-dead-letter", c.WebhookDeadLetter, "webhook 死信日志文件路径 (WEBHOOK_DEAD_LETTER)")
+	fs.StringVar(&c.ClusterAddr, "cluster-addr", c.ClusterAddr, "集群内部通信监听地址，为空时以单节点模式运行 (CLUSTER_ADDR)")
+	fs.StringVar(&c.ClusterAdvertise, "cluster-advertise", c.ClusterAdvertise, "其他节点连接当前节点使用的地址 (CLUSTER_ADVERTISE)")
+	fs.StringVar(&c.ClusterSecret, "cluster-secret", c.ClusterSecret, "集群共享密钥 (CLUSTER_SECRET)")
+	fs.DurationVar(&c.ClusterTTL, "cluster-ttl", c.ClusterTTL, "集群节点心跳超时时间 (CLUSTER_TTL)")
+	fs.Func("ws-origins", "允许的 websocket 请求来源，逗号分隔 (WS_ORIGINS)", func(s string) error {
+		c.WSOrigins = strings.Split(s, ",")
+		return nil
+	})
+	fs.Func("tls-hosts", "自签名证书包含的域名或 IP，逗号分隔 (TLS_HOSTS)", func(s string) error {
+		c.TLSHosts = strings.Split(s, ",")
+		return nil
+	})
+	return fs
+}
+
+// 只用于控制启动流程的命令行参数，不属于配置
+type cli_args_t struct {
+	config      string
+	printConfig bool
+	rotateKey   bool
+}
+
+// 读取配置文件
+func (c *config_t) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	// 配置文件中出现未知字段时报错，避免拼写错误被忽略
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// 读取环境变量
+func (c *config_t) loadEnv() (err error) {
+	str := func(name string, v *string) {
+		if val, found := os.LookupEnv(name); found {
+			*v = val
+		}
+	}
+	num := func(name string, v *int) {
+		if val, found := os.LookupEnv(name); found && err == nil {
+			if *v, err = strconv.Atoi(val); err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	dur := func(name string, v *time.Duration) {
+		if val, found := os.LookupEnv(name); found && err == nil {
+			if *v, err = time.ParseDuration(val); err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	str("SVR_ADDR", &c.Addr)
+	str("STORE", &c.Store)
+	str("DB_PATH", &c.DBPath)
+	str("DB_DSN", &c.DBDSN)
+	str("KEYS_PATH", &c.KeysPath)
+	str("TLS_CERT", &c.TLSCert)
+	str("TLS_KEY", &c.TLSKey)
+	str("SLOW_CONSUMER", &c.SlowConsumer)
+	str("WS_ADDR", &c.WSAddr)
+	str("API_ADDR", &c.APIAddr)
+	str("METRICS_ADDR", &c.MetricsAddr)
+	str("ADMIN_ADDR", &c.AdminAddr)
+	str("LOG_LEVEL", &c.LogLevel)
+	str("LOG_FORMAT", &c.LogFormat)
+	str("WEBHOOK_DEAD_LETTER", &c.WebhookDeadLetter)
+	str("CLUSTER_ADDR", &c.ClusterAddr)
+	str("CLUSTER_ADVERTISE", &c.ClusterAdvertise)
+	str("CLUSTER_SECRET", &c.ClusterSecret)
+	num("BCRYPT_COST", &c.BcryptCost)
+	num("SESSION_BUFFER", &c.SessionBuffer)
+	num("EVENT_BUFFER", &c.EventBuffer)
+	num("MIN_VERSION", &c.MinVersion)
+	num("MAX_FRAME_SIZE", &c.MaxFrameSize)
+	num("WEBHOOK_RETRIES", &c.WebhookRetries)
+	dur("TOKEN_TTL", &c.TokenTTL)
+	dur("SHUTDOWN_TIMEOUT", &c.ShutdownTimeout)
+	dur("RETRY_AFTER", &c.RetryAfter)
+	dur("HEARTBEAT_TIMEOUT", &c.HeartbeatTimeout)
+	dur("WRITE_TIMEOUT", &c.WriteTimeout)
+	dur("WEBHOOK_TIMEOUT", &c.WebhookTimeout)
+	dur("CLUSTER_TTL", &c.ClusterTTL)
+
+	if val, found := os.LookupEnv("NODE_ID"); found && err == nil {
+		if c.NodeId, err = strconv.ParseInt(val, 10, 64); err != nil {
+			err = fmt.Errorf("NODE_ID: %w", err)
+		}
+	}
+	if val, found := os.LookupEnv("DEBUG_SAMPLE_RATE"); found && err == nil {
+		if c.DebugSampleRate, err = strconv.ParseFloat(val, 64); err != nil {
+			err = fmt.Errorf("DEBUG_SAMPLE_RATE: %w", err)
+		}
+	}
+	if val, found := os.LookupEnv("TLS_DEV"); found {
+		c.TLSDev = val == "1"
+	}
+	if val, found := os.LookupEnv("TLS_HOSTS"); found {
+		c.TLSHosts = strings.Split(val, ",")
+	}
+	if val, found := os.LookupEnv("WS_ORIGINS"); found {
+		c.WSOrigins = strings.Split(val, ",")
+	}
+	return
+}
+
+// 校验配置
+func (c *config_t) validate() error {
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("addr: %w", err)
+	}
+	switch c.Store {
+	case STORE_SQLITE:
+		if len(c.DBPath) == 0 {
+			return errors.New("db_path: must not be empty")
+		}
+	case STORE_POSTGRES:
+		if len(c.DBDSN) == 0 {
+			return errors.New("db_dsn: must not be empty")
+		}
+	case STORE_MEMORY:
+	default:
+		return fmt.Errorf("store: must be %s, %s or %s", STORE_SQLITE, STORE_POSTGRES, STORE_MEMORY)
+	}
+	if len(c.KeysPath) == 0 {
+		return errors.New("keys_path: must not be empty")
+	}
+	if c.NodeId < 0 || c.NodeId > maxNodeId() {
+		return fmt.Errorf("node_id: must be between 0 and %d", maxNodeId())
+	}
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt_cost: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if c.TokenTTL <= 0 {
+		return errors.New("token_ttl: must be positive")
+	}
+	if c.SessionBuffer <= 0 {
+		return errors.New("session_buffer: must be positive")
+	}
+	if c.EventBuffer <= 0 {
+		return errors.New("event_buffer: must be positive")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("shutdown_timeout: must be positive")
+	}
+	if c.RetryAfter < 0 {
+		return errors.New("retry_after: must not be negative")
+	}
+	if c.HeartbeatTimeout <= 0 {
+		return errors.New("heartbeat_timeout: must be positive")
+	}
+	if c.WriteTimeout <= 0 {
+		return errors.New("write_timeout: must be positive")
+	}
+	if c.SlowConsumer != SLOW_CONSUMER_DISCONNECT && c.SlowConsumer != SLOW_CONSUMER_DROP {
+		return fmt.Errorf("slow_consumer: must be %s or %s", SLOW_CONSUMER_DISCONNECT, SLOW_CONSUMER_DROP)
+	}
+	if c.MinVersion < 0 || c.MinVersion > int(lib.PROTO_VERSION) {
+		return fmt.Errorf("min_version: must be between 0 and %d", lib.PROTO_VERSION)
+	}
+	if c.MaxFrameSize < 1<<10 || c.MaxFrameSize > 1<<30 {
+		return errors.New("max_frame_size: must be between 1KB and 1GB")
+	}
+	if len(c.WSAddr) > 0 {
+		if _, _, err := net.SplitHostPort(c.WSAddr); err != nil {
+			return fmt.Errorf("ws_addr: %w", err)
+		}
+	}
+	if len(c.APIAddr) > 0 {
+		if _, _, err := net.SplitHostPort(c.APIAddr); err != nil {
+			return fmt.Errorf("api_addr: %w", err)
+		}
+	}
+	if len(c.MetricsAddr) > 0 {
+		if _, _, err := net.SplitHostPort(c.MetricsAddr); err != nil {
+			return fmt.Errorf("metrics_addr: %w", err)
+		}
+	}
+	level, err := parseLogLevel(c.LogLevel)
+	if err != nil {
+		return fmt.Errorf("log_level: %w", err)
+	}
+	if f := strings.ToLower(c.LogFormat); f != LOG_FORMAT_JSON && f != LOG_FORMAT_TEXT {
+		return fmt.Errorf("log_format: must be %s or %s", LOG_FORMAT_JSON, LOG_FORMAT_TEXT)
+	}
+	if c.DebugSampleRate < 0 || c.DebugSampleRate > 1 {
+		return errors.New("debug_sample_rate: must be between 0 and 1")
+	}
+	if c.DebugSampleRate > 0 && level > slog.LevelDebug {
+		return errors.New("debug_sample_rate: requires log_level debug")
+	}
+	if len(c.AdminAddr) > 0 {
+		if _, _, err := net.SplitHostPort(c.AdminAddr); err != nil {
+			return fmt.Errorf("admin_addr: %w", err)
+		}
+	}
+	for i, hook := range c.Webhooks {
+		if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("webhooks[%d].url: must be an http or https url", i)
+		}
+		if len(hook.Secret) == 0 {
+			return fmt.Errorf("webhooks[%d].secret: must not be empty", i)
+		}
+		if len(hook.Users) == 0 && len(hook.Groups) == 0 && len(hook.Mentions) == 0 {
+			return fmt.Errorf("webhooks[%d]: users, groups and mentions must not all be empty", i)
+		}
+	}
+	if c.WebhookTimeout <= 0 {
+		return errors.New("webhook_timeout: must be positive")
+	}
+	if c.WebhookRetries < 0 {
+		return errors.New("webhook_retries: must not be negative")
+	}
+	if len(c.Webhooks) > 0 && len(c.WebhookDeadLetter) == 0 {
+		return errors.New("webhook_dead_letter: must not be empty")
+	}
+	if len(c.ClusterAddr) > 0 {
+		if _, _, err := net.SplitHostPort(c.ClusterAddr); err != nil {
+			return fmt.Errorf("cluster_addr: %w", err)
+		}
+		// 其他节点需要通过该地址连接，不能只有端口
+		if len(c.ClusterAdvertise) > 0 {
+			if host, _, err := net.SplitHostPort(c.ClusterAdvertise); err != nil || len(host) == 0 {
+				return errors.New("cluster_advertise: must be host:port")
+			}
+		} else if host, _, _ := net.SplitHostPort(c.ClusterAddr); len(host) == 0 {
+			return errors.New("cluster_advertise: must be set when cluster_addr has no host")
+		}
+		if len(c.ClusterSecret) == 0 {
+			return errors.New("cluster_secret: must not be empty")
+		}
+		// 节点注册、帐号和消息都保存在共享存储中
+		if c.Store != STORE_POSTGRES {
+			return fmt.Errorf("store: must be %s in cluster mode", STORE_POSTGRES)
+		}
+	}
+	if c.ClusterTTL <= 0 {
+		return errors.New("cluster_ttl: must be positive")
+	}
+	if (len(c.TLSCert) == 0) != (len(c.TLSKey) == 0) {
+		return errors.New("tls_cert and tls_key must be set together")
+	}
+	if c.TLSDev && len(c.TLSHosts) == 0 {
+		return errors.New("tls_hosts: must not be empty in tls_dev mode")
+	}
+	return nil
+}
+
+// 按优先级加载配置: 默认值、配置文件、环境变量、命令行参数
+func loadConfig(arguments []string) (c *config_t, args *cli_args_t, err error) {
+	// 先解析一次命令行参数，得到配置文件路径
+	args = &cli_args_t{config: os.Getenv("CONFIG")}
+	if err = defaultConfig().flagSet(args).Parse(arguments); err != nil {
+		return
+	}
+
+	c = defaultConfig()
+	if len(args.config) > 0 {
+		if err = c.loadFile(args.config); err != nil {
+			return
+		}
+	}
+	if err = c.loadEnv(); err != nil {
+		return
+	}
+
+	// 再次解析命令行参数，覆盖配置文件和环境变量
+	if err = c.flagSet(args).Parse(arguments); err != nil {
+		return
+	}
+
+	err = c.validate()
+	return
+}
+
+// 以 yaml 格式输出配置，密码和密钥替换为 REDACTED
+func (c *config_t) print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(c.redacted())
+}
+
+// 返回隐藏了密码和密钥的配置副本
+func (c *config_t) redacted() *config_t {
+	r := *c
+	r.DBDSN = redactDSN(c.DBDSN)
+	if len(r.ClusterSecret) > 0 {
+		r.ClusterSecret = REDACTED
+	}
+	r.Webhooks = make([]webhook_config_t, len(c.Webhooks))
+	for i, hook := range c.Webhooks {
+		if len(hook.Secret) > 0 {
+			hook.Secret = REDACTED
+		}
+		r.Webhooks[i] = hook
+	}
+	return &r
+}
+
+// key=value 格式连接串中的密码
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// 隐藏 postgres 连接串中的密码，支持 url 和 key=value 两种格式
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		// url.Redacted 使用 xxxxx 替换密码
+		return strings.Replace(u.Redacted(), ":xxxxx@", ":"+REDACTED+"@", 1)
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+REDACTED)
+}
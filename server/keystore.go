@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// token 密钥存储，支持多个密钥版本。新 token 使用当前版本密钥加密，旧版本密钥仍可解密之前签发的 token
+type keystore_t struct {
+	mu      sync.RWMutex
+	path    string
+	current uint32
+	keys    map[uint32]*[32]byte
+}
+
+// 密钥文件格式
+type keyfile_t struct {
+	Current uint32            `json:"current"`
+	Keys    map[uint32]string `json:"keys"`
+}
+
+// token 密钥存储，启动时由 main 初始化
+var tokenKeys *keystore_t
+
+// 加载密钥文件，文件不存在时生成随机密钥并保存。设置了 SECRET_KEY 环境变量时使用该值作为第一个密钥
+//
+// shared 为 true 时 (集群模式) 所有节点必须使用相同的密钥，否则一个节点签发的 token 在其他节点上无法验证。此时不生成随机密钥，密钥文件不存在并且没有设置 SECRET_KEY 时返回 error
+func loadKeyStore(path string, shared bool) (ks *keystore_t, err error) {
+	ks = &keystore_t{path: path, keys: make(map[uint32]*[32]byte)}
+
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		secretKey, found := os.LookupEnv("SECRET_KEY")
+		if !found && shared {
+			return nil, fmt.Errorf("keys_path: %s not found, cluster mode requires the same keys file or SECRET_KEY on every node", path)
+		}
+		key := lib.NewEncryptionKey()
+		if found {
+			if key, err = decodeKey(secretKey); err != nil {
+				return nil, err
+			}
+		}
+		ks.current = 1
+		ks.keys[ks.current] = key
+		return ks, ks.save()
+	} else if err != nil {
+		return
+	}
+
+	kf := &keyfile_t{}
+	if err = json.Unmarshal(bytes, kf); err != nil {
+		return
+	}
+	for kid, val := range kf.Keys {
+		if ks.keys[kid], err = decodeKey(val); err != nil {
+			return
+		}
+	}
+	if _, found := ks.keys[kf.Current]; !found {
+		return nil, errors.New("current key not exist")
+	}
+	ks.current = kf.Current
+	return
+}
+
+func decodeKey(val string) (key *[32]byte, err error) {
+	bytes, err := hex.DecodeString(val)
+	if err != nil {
+		return
+	}
+	if len(bytes) != 32 {
+		return nil, errors.New("invalid key length")
+	}
+	return (*[32]byte)(bytes), nil
+}
+
+// 保存密钥文件，只有当前用户可读写
+func (ks *keystore_t) save() error {
+	kf := &keyfile_t{Current: ks.current, Keys: make(map[uint32]string, len(ks.keys))}
+	for kid, key := range ks.keys {
+		kf.Keys[kid] = hex.EncodeToString(key[:])
+	}
+
+	bytes, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致密钥丢失
+	tmp := ks.path + ".tmp"
+	if err := os.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+// 生成新版本密钥并设为当前密钥，旧版本密钥保留
+func (ks *keystore_t) rotate() (kid uint32, err error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for id := range ks.keys {
+		if id > kid {
+			kid = id
+		}
+	}
+	kid++
+	ks.keys[kid] = lib.NewEncryptionKey()
+	ks.current = kid
+	return kid, ks.save()
+}
+
+// 返回当前版本密钥
+func (ks *keystore_t) currentKey() (kid uint32, key *[32]byte) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current, ks.keys[ks.current]
+}
+
+// 返回指定版本密钥
+func (ks *keystore_t) key(kid uint32) (key *[32]byte, found bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, found = ks.keys[kid]
+	return
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 集群模式下不生成随机密钥，所有节点使用 SECRET_KEY 或同一个密钥文件
+func TestKeyStoreShared(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.keys")
+
+	// t.Setenv 在测试结束后恢复原值
+	t.Setenv("SECRET_KEY", "")
+	os.Unsetenv("SECRET_KEY")
+	if _, err := loadKeyStore(path, true); err == nil {
+		t.Fatal("cluster mode without shared key should fail")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("keys file should not be generated in cluster mode")
+	}
+
+	secret := strings.Repeat("ab", 32)
+	t.Setenv("SECRET_KEY", secret)
+	a, err := loadKeyStore(filepath.Join(dir, "a.keys"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := loadKeyStore(filepath.Join(dir, "b.keys"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kidA, keyA := a.currentKey()
+	kidB, keyB := b.currentKey()
+	if kidA != kidB || *keyA != *keyB {
+		t.Fatal("nodes with the same SECRET_KEY should share the key")
+	}
+
+	// 已存在的密钥文件不需要 SECRET_KEY
+	os.Unsetenv("SECRET_KEY")
+	if _, err := loadKeyStore(filepath.Join(dir, "a.keys"), true); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 日志格式
+const (
+	// 每行一个 json 对象
+	LOG_FORMAT_JSON = "json"
+	// key=value 格式，便于开发时阅读
+	LOG_FORMAT_TEXT = "text"
+)
+
+// 解析日志级别: debug、info、warn 或 error
+func parseLogLevel(s string) (level slog.Level, err error) {
+	err = level.UnmarshalText([]byte(s))
+	return
+}
+
+// 根据配置创建日志，标准库 log 的输出也会通过 slog.SetDefault 转为 info 级别的结构化日志
+func newLogger(c *config_t, w io.Writer) (*slog.Logger, error) {
+	level, err := parseLogLevel(c.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(c.LogFormat) {
+	case LOG_FORMAT_JSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case LOG_FORMAT_TEXT:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %s", c.LogFormat)
+	}
+}
+
+// 连接日志，每行都带有 sid、远端地址以及当前登录的帐号 id 和用户名
+type conn_log_t struct {
+	logger  *slog.Logger
+	account *atomic.Pointer[conn_account_t]
+}
+
+func newConnLog(sid uint64, remote string, account *atomic.Pointer[conn_account_t]) *conn_log_t {
+	return &conn_log_t{slog.Default().With("sid", sid, "remote", remote), account}
+}
+
+func (l *conn_log_t) log(level slog.Level, msg string, args ...any) {
+	if !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	a := l.account.Load()
+	l.logger.With("acc_id", a.id, "username", a.username).Log(context.Background(), level, msg, args...)
+}
+
+func (l *conn_log_t) Error(msg string, args ...any) {
+	l.log(slog.LevelError, msg, args...)
+}
+
+func (l *conn_log_t) Warn(msg string, args ...any) {
+	l.log(slog.LevelWarn, msg, args...)
+}
+
+func (l *conn_log_t) Info(msg string, args ...any) {
+	l.log(slog.LevelInfo, msg, args...)
+}
+
+// 按照 conf.DebugSampleRate 采样输出 packet 元数据，dir 为 in 或 out。只输出类型、id 和长度，不会输出消息内容
+func (l *conn_log_t) packet(dir string, pack *lib.Packet) {
+	if conf.DebugSampleRate <= 0 || rand.Float64() >= conf.DebugSampleRate {
+		return
+	}
+	l.log(slog.LevelDebug, "packet", "dir", dir, "kind", pack.Kind.String(), "pid", pack.Id, "size", len(pack.Data))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 连接日志带有连接信息，采样输出的 packet 只包含元数据
+func TestConnLog(t *testing.T) {
+	defer func(c *config_t, l *slog.Logger) {
+		conf = c
+		slog.SetDefault(l)
+	}(conf, slog.Default())
+	conf = defaultConfig()
+	conf.LogLevel = "debug"
+	conf.DebugSampleRate = 1
+
+	var buf bytes.Buffer
+	logger, err := newLogger(conf, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	var account atomic.Pointer[conn_account_t]
+	account.Store(&conn_account_t{})
+	l := newConnLog(7, "127.0.0.1:5000", &account)
+	// 登录后的日志带有帐号信息
+	account.Store(&conn_account_t{42, "alice"})
+	l.packet("in", &lib.Packet{Id: 3, Kind: lib.PackKind_MSG, Data: []byte("top secret")})
+
+	if strings.Contains(buf.String(), "top secret") {
+		t.Fatal("packet body must not be logged")
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"level":    "DEBUG",
+		"sid":      float64(7),
+		"remote":   "127.0.0.1:5000",
+		"acc_id":   float64(42),
+		"username": "alice",
+		"dir":      "in",
+		"kind":     "MSG",
+		"pid":      float64(3),
+		"size":     float64(10),
+	} {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %v", key, line[key], want)
+		}
+	}
+
+	// 不采样时不输出
+	buf.Reset()
+	conf.DebugSampleRate = 0
+	l.packet("out", &lib.Packet{Kind: lib.PackKind_PUSH})
+	if buf.Len() > 0 {
+		t.Errorf("unexpected log %s", buf.String())
+	}
+}
@@ -1,18 +1,22 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"errors"
-	"log"
+	"flag"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
 )
 
 // 信号监听处理器
@@ -24,38 +28,175 @@ func signalHandler() {
 	signal.Notify(sigChan, os.Interrupt)    // ctrl+c
 	signal.Notify(sigChan, syscall.SIGTERM) // kill
 
-	// 一直阻塞，直到收到信号，恢复执行并退出进程
+	// 一直阻塞，直到收到信号
 	<-sigChan
-	// 退出进程
-	defer os.Exit(0)
+	// 再次收到信号时直接退出进程
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
 }
 
-// 把来自 packChan 的 packet 都发送到 conn
-func sendTo(conn net.Conn, packChan <-chan *lib.Packet, c <-chan *lib.Push, accId *uint64, accUN *string, storage *storage_t) {
-	// 间隔 100ms 检查是否有新消息
-	interval := time.NewTicker(100 * time.Millisecond)
-	defer interval.Stop()
+// 清理失效 session 的间隔
+const SESSION_GC_INTERVAL = time.Hour
+
+// 定期删除已撤销或者超过 token 有效期没有刷新过的 session
+func pruneSessions(storage store_i) {
+	for {
+		n, err := storage.PruneSessions(time.Now().Add(-conf.TokenTTL))
+		if err != nil {
+			slog.Warn("prune sessions", "err", err)
+		} else if n > 0 {
+			slog.Info("pruned sessions", "count", n)
+		}
+		time.Sleep(SESSION_GC_INTERVAL)
+	}
+}
+
+// 关闭服务器: 停止接受新连接，通知所有连接服务器即将关闭，等待连接发送完待发送数据后重置所有用户在线状态
+//
+// 集群模式下只离开集群，当前节点上的用户由其他节点设置为离线
+func shutdown(listeners []net.Listener, api *http.Server, quit chan struct{}, accepting, conns *sync.WaitGroup, eventChan chan<- event_i, storage store_i, cluster *cluster_t) {
+	slog.Info("shutting down")
+	// 就绪检查失败，负载均衡不再转发新连接
+	serverReady.Store(false)
+
+	eventChan <- &e_shutdown_t{}
+	close(quit)
+	for _, ln := range listeners {
+		ln.Close()
+	}
+	accepting.Wait()
+
+	// 等待正在处理的 HTTP API 请求完成
+	if api != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+		if err := api.Shutdown(ctx); err != nil {
+			slog.Warn("api shutdown", "err", err)
+		}
+		cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(conf.ShutdownTimeout):
+		slog.Warn("shutdown timeout, closing remaining connections")
+	}
+
+	if cluster != nil {
+		cluster.leave()
+	} else {
+		if err := storage.ResetOnline(); err != nil {
+			slog.Error("reset online", "err", err)
+		}
+	}
+	if err := storage.Close(); err != nil {
+		slog.Error("close storage", "err", err)
+	}
+}
+
+// 服务器即将关闭: 停止读取新请求，在超时前发送完 packChan 中待发送的 packet，最后发送 GOAWAY
+func goAway(conn net.Conn, packChan <-chan *lib.Packet, sendPack func(*lib.Packet) error, l *conn_log_t) {
+	// 让 recvFrom 协程的读取立即返回，recvFrom 处理完当前请求后会关闭 packChan
+	conn.SetReadDeadline(time.Now())
+
+	deadline := time.After(conf.ShutdownTimeout)
+drain:
+	for {
+		select {
+		case pack, ok := <-packChan:
+			if !ok {
+				break drain
+			}
+			if err := sendPack(pack); err != nil {
+				return
+			}
+		case <-deadline:
+			break drain
+		}
+	}
+
+	bytes, err := lib.Marshal(&lib.GoAway{RetryAfter: int32(conf.RetryAfter / time.Second), Reason: "server shutting down"})
+	if err != nil {
+		l.Error("marshal packet", "kind", lib.PackKind_GOAWAY.String(), "err", err)
+		return
+	}
+	sendPack(&lib.Packet{Kind: lib.PackKind_GOAWAY, Data: bytes})
+}
+
+// 把来自 packChan 的 packet 以及来自 c 的 push 和新消息都发送到 conn。quit 关闭时表示服务器即将关闭，kick 关闭时表示 hub 要求断开连接
+func sendTo(conn net.Conn, packChan <-chan *lib.Packet, c <-chan proto.Message, quit, kick <-chan struct{}, eventChan chan<- event_i, account *atomic.Pointer[conn_account_t], storage store_i, l *conn_log_t) {
 	var pid uint64
+	w := lib.NewFrameWriter(conn, uint32(conf.MaxFrameSize))
 
+	// 发送失败时输出日志，调用方直接退出
 	var sendPack = func(pack *lib.Packet) (err error) {
 		if pack.Id == 0 {
 			pid++
 			pack.Id = pid
 		}
+		l.packet("out", pack)
+
+		// 客户端长时间不读取数据时写入超时，避免协程永久阻塞
+		conn.SetWriteDeadline(time.Now().Add(conf.WriteTimeout))
+		err = w.WritePack(pack)
+		if err == nil {
+			metricPacketsOut.WithLabelValues(pack.Kind.String()).Inc()
+			if pack.Kind == lib.PackKind_MSG {
+				metricMsgsDelivered.Inc()
+			}
+		}
 
-		bytes, err := lib.MarshalPack(pack)
-		if err != nil {
+		// packet 超过最大长度时没有写入任何数据，同步响应改为返回错误码，其他 packet 直接丢弃
+		var sizeErr *lib.FrameSizeError
+		if errors.As(err, &sizeErr) {
+			l.Warn("packet too large", "kind", pack.Kind.String(), "pid", pack.Id, "err", err)
+			if pack.Kind != lib.PackKind_RES {
+				return nil
+			}
+
+			bytes, err := lib.Marshal(&lib.ErrRes{Code: lib.Err_Frame_Too_Large.Val()})
+			if err == nil {
+				err = w.WritePack(&lib.Packet{Id: pack.Id, Kind: lib.PackKind_RES, Data: bytes})
+			}
+			if err != nil {
+				l.Warn("write packet", "kind", lib.PackKind_RES.String(), "pid", pack.Id, "err", err)
+			}
 			return err
 		}
-
-		_, err = conn.Write(bytes)
+		if err != nil {
+			l.Warn("write packet", "kind", pack.Kind.String(), "pid", pack.Id, "err", err)
+		}
 		return
 	}
 
+	// hub 要求断开连接时直接关闭 conn，避免阻塞在写入慢速连接上
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-kick:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
 loop:
 	for {
 		select {
 
+		// 服务器即将关闭
+		case <-quit:
+			goAway(conn, packChan, sendPack, l)
+			return
+
+		// 发送队列已满，hub 要求断开连接
+		case <-kick:
+			return
+
 		// 发送 packet 到客户端
 		case pack, ok := <-packChan:
 			if !ok { // recvFrom 协程已退出，需要退出当前协程
@@ -63,76 +204,93 @@ loop:
 			}
 
 			if err := sendPack(pack); err != nil {
-				log.Println(err)
 				return
 			}
 
-		// 发送 push 到客户端
-		case push := <-c:
-			// 上下线提醒不用发给自己
-			if push.Kind == lib.PushKind_ONLINE {
-				online := &lib.Online{}
-				if err := lib.Unmarshal(push.Data, online); err != nil {
-					log.Println(err)
+		// 发送 push 或新消息到客户端
+		case m := <-c:
+			switch m := m.(type) {
+			case *lib.Push:
+				// 上下线提醒不用发给自己
+				if m.Kind == lib.PushKind_ONLINE {
+					online := &lib.Online{}
+					if err := lib.Unmarshal(m.Data, online); err != nil {
+						l.Error("unmarshal push", "kind", lib.PackKind_PUSH.String(), "err", err)
+						return
+					}
+					if online.Username == account.Load().username {
+						continue loop
+					}
+				}
+
+				bytes, err := lib.Marshal(m)
+				if err != nil {
+					l.Error("marshal packet", "kind", lib.PackKind_PUSH.String(), "err", err)
 					return
 				}
-				if online.Username == *accUN {
-					continue loop
+
+				if err := sendPack(&lib.Packet{
+					Kind: lib.PackKind_PUSH,
+					Data: bytes,
+				}); err != nil {
+					return
 				}
-			}
 
-			bytes, err := lib.Marshal(push)
-			if err != nil {
-				log.Println(err)
-				return
-			}
+			// 转发新消息，转发成功后标记为已转发，并向发送者发送回执
+			case *lib.Msg:
+				bytes, err := lib.Marshal(m)
+				if err != nil {
+					l.Error("marshal packet", "kind", lib.PackKind_MSG.String(), "err", err)
+					return
+				}
 
-			pack := &lib.Packet{
-				Kind: lib.PackKind_PUSH,
-				Data: bytes,
-			}
-			if err := sendPack(pack); err != nil {
-				log.Println(err)
-				return
-			}
+				if err := sendPack(&lib.Packet{
+					Kind: lib.PackKind_MSG,
+					Data: bytes,
+				}); err != nil {
+					return
+				}
 
-		// 读取并转发所有发送给当前用户的未读消息
-		case <-interval.C:
-			if len(*accUN) > 0 { // accUN 用户已登录客户端
-				// 查询所有发送给 accUN 的未读消息
-				msgList, _ := storage.GetMsgList(*accUN)
-				for i := range msgList {
-					msg := &lib.Msg{
-						Id:   msgList[i].Id,
-						Kind: lib.MsgKind(msgList[i].Kind),
-						From: msgList[i].From,
-						To:   msgList[i].To,
-						Data: msgList[i].Data,
-					}
+				// 自己从其他设备发送的消息不需要标记和回执
+				if m.To != account.Load().username {
+					continue loop
+				}
 
-					bytes, err := lib.Marshal(msg)
-					if err != nil {
-						log.Println(err)
-						return
+				ok, err := storage.UpdateDelivered(m.Id, m.To)
+				if err != nil {
+					l.Error("update delivered", "kind", lib.PackKind_MSG.String(), "msg_id", m.Id, "err", err)
+					continue loop
+				}
+				if ok {
+					if err := sendReceipts(eventChan, lib.MsgStatus_DELIVERED, m.To, []Message{{Id: m.Id, From: m.From, Group: m.Group}}); err != nil {
+						l.Error("send receipts", "kind", lib.PackKind_MSG.String(), "msg_id", m.Id, "err", err)
 					}
+				}
 
-					// 转发消息
-					if err := sendPack(&lib.Packet{
-						Kind: lib.PackKind_MSG,
-						Data: bytes,
-					}); err != nil {
-						log.Println(err)
-						return
-					}
+			// 当前 session 已撤销，发送错误并断开连接
+			case *lib.ErrRes:
+				bytes, err := lib.Marshal(m)
+				if err != nil {
+					l.Error("marshal packet", "kind", lib.PackKind_ERR.String(), "err", err)
+					return
 				}
+
+				l.Info("session revoked")
+				sendPack(&lib.Packet{
+					Kind: lib.PackKind_ERR,
+					Data: bytes,
+				})
+				return
 			}
 		}
 	}
 }
 
 // 根据 kind 返回对应的后台处理逻辑 biz
-func kindToBiz(kind lib.PackKind, b biz_base_t, node *snowflake.Node) (biz biz_i, err error) {
+func kindToBiz(kind lib.PackKind, b biz_base_t, node *snowflake.Node, webhooks *webhooks_t) (biz biz_i, err error) {
 	switch kind {
+	case lib.PackKind_HELLO:
+		biz = initialHello(b)
 	case lib.PackKind_PING:
 		biz = initialPing(b)
 	case lib.PackKind_SIGNUP:
@@ -146,123 +304,345 @@ func kindToBiz(kind lib.PackKind, b biz_base_t, node *snowflake.Node) (biz biz_i
 	case lib.PackKind_USERS:
 		biz = initialUsers(b)
 	case lib.PackKind_MSG:
-		biz = initialRecvMsg(b, node)
+		biz = initialRecvMsg(b, node, webhooks)
+	case lib.PackKind_GROUP_CREATE:
+		biz = initialGroupCreate(b)
+	case lib.PackKind_GROUP_INVITE:
+		biz = initialGroupInvite(b)
+	case lib.PackKind_GROUP_KICK:
+		biz = initialGroupKick(b)
+	case lib.PackKind_GROUP_LEAVE:
+		biz = initialGroupLeave(b)
+	case lib.PackKind_GROUPS:
+		biz = initialGroups(b)
+	case lib.PackKind_HISTORY:
+		biz = initialHistory(b)
+	case lib.PackKind_RECEIPT:
+		biz = initialReceipt(b)
+	case lib.PackKind_PUBLISH_KEY:
+		biz = initialPublishKey(b)
+	case lib.PackKind_GET_KEY:
+		biz = initialGetKey(b)
+	case lib.PackKind_SESSIONS:
+		biz = initialSessions(b)
+	case lib.PackKind_REVOKE:
+		biz = initialRevoke(b)
 	default:
 		err = errors.New("invalid kind of packet")
 	}
 	return
 }
 
-// 读取并处理客户端发送的 packet
-func recvFrom(conn net.Conn, b biz_base_t, accId *uint64, accUN *string, node *snowflake.Node) {
+// 读取并处理客户端发送的 packet。客户端会定时发送 ping，超过 conf.HeartbeatTimeout 没有收到任何 packet 时认为连接已断开
+func recvFrom(conn net.Conn, b biz_base_t, quit <-chan struct{}, account *atomic.Pointer[conn_account_t], node *snowflake.Node, webhooks *webhooks_t, l *conn_log_t) {
 	defer b.close()
 
-	// 设置如何处理接收到的字节流，SplitFunc 会根据 packet 开头 length 把字节流分割为消息流
-	scanner := bufio.NewScanner(conn)
-	scanner.Split(lib.SplitFunc)
+	// 当前连接所登录的用户，只在当前协程中读写，登录或退出后通过 account 发布给其他协程
+	var (
+		accId uint64
+		accUN string
+	)
+
+	// 按照 packet 开头 length 把字节流分割为消息流，length 超过 conf.MaxFrameSize 时不再读取
+	r := lib.NewFrameReader(conn, uint32(conf.MaxFrameSize))
+
+	// 是否为连接上的第一个 packet
+	first := true
+
+	// 循环解析消息，每读取一个 packet 执行一次处理逻辑
+	var err error
+	for {
+		// 每次读取前刷新读超时
+		conn.SetReadDeadline(time.Now().Add(conf.HeartbeatTimeout))
+		// 设置读超时后再检查 quit，确保服务器关闭时 goAway 设置的读超时不会被覆盖
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		var frame []byte
+		if frame, err = r.Next(); err != nil {
+			break
+		}
 
-	// 循环解析消息，每当解析出一条消息后，scan() 返回 true
-	for scanner.Scan() {
-		// 把 scanner 解析出的消息字节 slice 解析为 Pack
+		// 把读取到的消息字节 slice 解析为 Pack
 		pack := &lib.Packet{}
-		if err := lib.Unmarshal(scanner.Bytes(), pack); err != nil {
-			log.Println(err)
+		if err := lib.Unmarshal(frame, pack); err != nil {
+			l.Warn("unmarshal packet", "size", len(frame), "err", err)
 			return
 		}
+		l.packet("in", pack)
+
+		// 第一个 packet 不是 HELLO 时为未握手的旧版本客户端，协议版本为 0
+		if first {
+			first = false
+			if pack.Kind != lib.PackKind_HELLO && conf.MinVersion > 0 {
+				l.Warn("client without handshake rejected", "kind", pack.Kind.String())
+				b.poster.Send(&lib.ErrRes{Code: lib.Err_Version_Unsupported.Val()})
+				return
+			}
+		}
 
 		// 获取 packet 处理逻辑
-		biz, err := kindToBiz(pack.Kind, b, node)
+		biz, err := kindToBiz(pack.Kind, b, node, webhooks)
 		if err != nil {
-			log.Println(err)
-			return
+			// 未知类型不使用 kind 作为标签，避免客户端产生任意多的指标
+			metricPacketsIn.WithLabelValues("UNKNOWN").Inc()
+			// 不支持的 packet 类型，返回错误码但不断开连接，新版本客户端可以继续使用其他功能
+			l.Warn("unknown packet kind", "kind", pack.Kind.String())
+			if err := b.poster.Handle(pack, &lib.ErrRes{Code: lib.Err_Unknown_Kind.Val()}); err != nil {
+				l.Warn("post response", "kind", pack.Kind.String(), "err", err)
+				return
+			}
+			continue
 		}
 
 		// 执行 packet 处理逻辑
-		if err := biz.do(pack, accId, accUN); err != nil {
-			log.Println(err)
+		kind := pack.Kind.String()
+		metricPacketsIn.WithLabelValues(kind).Inc()
+		start := time.Now()
+		err = biz.do(pack, &accId, &accUN)
+		metricBizDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+		if a := account.Load(); a.id != accId || a.username != accUN {
+			account.Store(&conn_account_t{accId, accUN})
+		}
+		if err != nil {
+			l.Warn("handle packet", "kind", kind, "pid", pack.Id, "err", err)
 			return
 		}
 	}
+
+	// 客户端发送的 packet 过大，返回错误码后断开连接
+	var sizeErr *lib.FrameSizeError
+	if errors.As(err, &sizeErr) {
+		l.Warn("packet too large", "err", err)
+		b.poster.Send(&lib.ErrRes{Code: lib.Err_Frame_Too_Large.Val()})
+		return
+	}
+
+	// 心跳超时，之后 handleConn 会发送下线事件。服务器关闭时 goAway 设置的读超时不算
+	if err, ok := err.(net.Error); ok && err.Timeout() {
+		select {
+		case <-quit:
+		default:
+			l.Info("heartbeat timeout")
+		}
+	}
 }
 
-func handleConn(conn net.Conn, sid uint64, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage *storage_t, node *snowflake.Node) {
+// 连接登录的帐号，未登录时为空
+type conn_account_t struct {
+	id       uint64
+	username string
+}
+
+func handleConn(conn net.Conn, sid uint64, quit <-chan struct{}, eventChan chan<- event_i, pushChan chan<- *lib.Push, storage store_i, node *snowflake.Node, webhooks *webhooks_t) {
 	// 从当前方法返回后，断开连接，清理资源等
 	defer conn.Close()
 
+	metricSessions.Inc()
+	defer metricSessions.Dec()
+
 	// 当前连接所登录的用户
-	var (
-		accId uint64
-		accUN string
-	)
+	var account atomic.Pointer[conn_account_t]
+	account.Store(&conn_account_t{})
 
 	// 断开连接后，更新用户在线状态
 	defer func() {
-		if accId > 0 {
-			storage.UpdateOnline(accId, false)
-
-			// 下线事件
+		if account.Load().id > 0 {
+			// 下线事件，用户最后一个 session 下线时会更新在线状态并发送下线提醒
 			eventChan <- &e_offline_t{sid}
-
-			// 下线提醒
-			bytes, err := lib.Marshal(&lib.Online{Kind: lib.OnlineKind_OFF, Username: accUN})
-			lib.FatalNotNil(err)
-
-			pushChan <- &lib.Push{
-				Kind: lib.PushKind_ONLINE,
-				Data: bytes,
-			}
 		}
 	}()
 
 	// 通过该 channel 可向当前连接发送 packet
-	packChan := make(chan *lib.Packet, 1024)
+	packChan := make(chan *lib.Packet, conf.SessionBuffer)
 	var poster lib.Post = newPoster(packChan)
 	base := initialBase(sid, poster, eventChan, pushChan, storage)
 
+	// 管理接口查询当前连接
+	remote := conn.RemoteAddr().String()
+	liveConns.Store(sid, &conn_info_t{sid, remote, time.Now(), packChan, base.c})
+	defer liveConns.Delete(sid)
+
+	l := newConnLog(sid, remote, &account)
+	l.logger.Debug("connected")
+	defer l.logger.Debug("disconnected")
+
 	// 为每个客户端启动一个协程，读取并处理客户端发送的 packet
-	go recvFrom(conn, base, &accId, &accUN, node)
+	go recvFrom(conn, base, quit, &account, node, webhooks, l)
 
 	// 当前协程调用并阻塞于 sendTo 函数，把来自 packChan 的 packet 都发送到 conn
-	sendTo(conn, packChan, base.c, &accId, &accUN, storage)
+	sendTo(conn, packChan, base.c, quit, base.kick, eventChan, &account, storage, l)
 }
 
 func main() {
-	// 启动单独协程，监听 ctrl+c 或 kill 信号，收到信号结束进程
-	go signalHandler()
+	// 加载配置
+	c, args, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	lib.FatalNotNil(err)
+	conf = c
+
+	// 结构化日志，之后标准库 log 的输出也使用相同的格式
+	logger, err := newLogger(conf, os.Stderr)
+	lib.FatalNotNil(err)
+	slog.SetDefault(logger)
+
+	if args.printConfig {
+		lib.FatalNotNil(conf.print(os.Stdout))
+		return
+	}
+
+	// 加载 token 密钥，集群中所有节点使用相同的密钥
+	keys, err := loadKeyStore(conf.KeysPath, len(conf.ClusterAddr) > 0)
+	lib.FatalNotNil(err)
+	tokenKeys = keys
+
+	if args.rotateKey {
+		kid, err := tokenKeys.rotate()
+		lib.FatalNotNil(err)
+		slog.Info("rotated token key", "kid", kid)
+		return
+	}
 
 	// 初始化存储
-	storage, err := new(storage_t).Init(filepath.Join(lib.WorkDir, "server.db"))
+	storage, err := openStore(conf)
 	lib.FatalNotNil(err)
 
-	// tcp 监听地址 0.0.0.0:8888
-	addr := ":8888"
-	// tcp 监听
-	ln, err := net.Listen("tcp", addr)
+	// 上次可能没有正常关闭，重置所有用户在线状态。集群中还有其他节点在运行时，在线状态由其他节点维护
+	if len(conf.ClusterAddr) == 0 {
+		lib.FatalNotNil(storage.ResetOnline())
+	} else if nodes, err := storage.GetNodes(conf.ClusterTTL); err != nil {
+		lib.FatalNotNil(err)
+	} else if len(nodes) == 0 {
+		lib.FatalNotNil(storage.ResetOnline())
+	}
+
+	// tcp 监听，配置了证书时使用 tls
+	tlsConfig, err := serverTLSConfig(conf)
+	lib.FatalNotNil(err)
+	ln, err := listen(conf.Addr, tlsConfig)
 	// tcp 监听遇到错误退出进程
 	lib.FatalNotNil(err)
 	// 输出日志
-	lib.LogMessage("Listening on", addr)
+	slog.Info("listening", "addr", conf.Addr)
+	listeners := []net.Listener{ln}
+
+	// websocket 监听，websocket 连接与 tcp 连接使用相同的处理逻辑
+	if len(conf.WSAddr) > 0 {
+		wsln, err := listenWS(conf, tlsConfig)
+		lib.FatalNotNil(err)
+		slog.Info("websocket listening", "addr", conf.WSAddr, "path", WS_PATH)
+		listeners = append(listeners, wsln)
+	}
+
+	// 定期清理失效的登录 session
+	go pruneSessions(storage)
+
+	eventChan := make(chan event_i, conf.EventBuffer)
+	pushChan := make(chan *lib.Push, conf.EventBuffer)
+	// 开启独立协程处理 push
+	go handlePush(eventChan, pushChan, storage)
+	registerQueueMetrics(eventChan, pushChan)
+
+	// prometheus 指标，服务器关闭过程中仍然可以访问
+	var metricsSrv *http.Server
+	if len(conf.MetricsAddr) > 0 {
+		metricsSrv, err = serveMetrics(conf)
+		lib.FatalNotNil(err)
+		slog.Info("metrics listening", "addr", conf.MetricsAddr)
+	}
+
+	// 管理接口，服务器关闭过程中仍然可以访问
+	var adminSrv *http.Server
+	if len(conf.AdminAddr) > 0 {
+		adminSrv, err = serveAdmin(conf, eventChan, storage)
+		lib.FatalNotNil(err)
+		slog.Info("admin listening", "addr", conf.AdminAddr)
+	}
+
+	// 集群模式下注册节点，使用分配的节点 id
+	nodeId := conf.NodeId
+	var cluster *cluster_t
+	if len(conf.ClusterAddr) > 0 {
+		cluster, err = joinCluster(conf, storage, eventChan)
+		lib.FatalNotNil(err)
+		nodeId = cluster.id()
+		slog.Info("cluster listening", "addr", conf.ClusterAddr, "node", nodeId)
+	}
 
 	// 创建 snowflake Node
-	node, err := snowflake.NewNode(1)
+	node, err := snowflake.NewNode(nodeId)
 	lib.FatalNotNil(err)
 
-	eventChan := make(chan event_i, 1024)
-	pushChan := make(chan *lib.Push, 1024)
-	// 开启独立协程处理 push
-	go handlePush(eventChan, pushChan)
+	// 消息 webhook，没有配置时为 nil
+	webhooks, err := newWebhooks(conf)
+	lib.FatalNotNil(err)
 
-	var sid uint64
-	// 循环接受客户端连接
-	for {
-		// 每当有客户端连接时，ln.Accept 会返回新的连接 conn
-		conn, err := ln.Accept()
-		// 如果接受的新连接遇到错误，则退出进程
+	// quit 关闭时通知所有连接服务器即将关闭
+	quit := make(chan struct{})
+	// 等待所有连接处理完成
+	var conns sync.WaitGroup
+	// HTTP API
+	var api *http.Server
+	if len(conf.APIAddr) > 0 {
+		api, err = serveAPI(conf, tlsConfig, eventChan, pushChan, storage, node, webhooks)
 		lib.FatalNotNil(err)
+		slog.Info("api listening", "addr", conf.APIAddr)
+	}
+
+	// 所有监听器停止接受新连接
+	var accepting sync.WaitGroup
+	// 连接 id，所有监听器共用
+	var sid uint64
 
-		sid++
+	for _, ln := range listeners {
+		accepting.Add(1)
+		go func(ln net.Listener) {
+			defer accepting.Done()
+
+			// 循环接受客户端连接
+			for {
+				// 每当有客户端连接时，ln.Accept 会返回新的连接 conn
+				conn, err := ln.Accept()
+				if err != nil {
+					select {
+					case <-quit: // 服务器正在关闭
+						return
+					default:
+					}
+					// 如果接受的新连接遇到错误，则退出进程
+					lib.FatalNotNil(err)
+				}
 
-		// 启动新协程处理当前连接
-		go handleConn(conn, sid, eventChan, pushChan, storage, node)
+				// 启动新协程处理当前连接
+				conns.Add(1)
+				go func(sid uint64) {
+					defer conns.Done()
+					handleConn(conn, sid, quit, eventChan, pushChan, storage, node, webhooks)
+				}(atomic.AddUint64(&sid, 1))
+			}
+		}(ln)
+	}
+
+	serverReady.Store(true)
+
+	// 阻塞直到收到 ctrl+c 或 kill 信号，然后关闭服务器
+	signalHandler()
+	shutdown(listeners, api, quit, &accepting, &conns, eventChan, storage, cluster)
+	// 所有连接处理完成后不会再产生新的 webhook 事件，未投递成功的事件写入死信日志
+	webhooks.close()
+	if metricsSrv != nil {
+		if err := metricsSrv.Close(); err != nil {
+			slog.Warn("close metrics listener", "err", err)
+		}
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Close(); err != nil {
+			slog.Warn("close admin listener", "err", err)
+		}
 	}
 }
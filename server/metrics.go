@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// 所有服务器指标都注册到该 registry，通过 metrics_addr 的 /metrics 导出
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	metrics = promauto.With(metricsRegistry)
+
+	// 当前连接数，包括未登录的连接
+	metricSessions = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "gochat_sessions",
+		Help: "Number of connected sessions.",
+	})
+	// 当前节点上已登录的用户数
+	metricUsers = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "gochat_users",
+		Help: "Number of logged-in users on this node.",
+	})
+	metricPacketsIn = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "gochat_packets_received_total",
+		Help: "Packets received from clients by kind.",
+	}, []string{"kind"})
+	metricPacketsOut = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "gochat_packets_sent_total",
+		Help: "Packets sent to clients by kind.",
+	}, []string{"kind"})
+	// biz 处理一个 packet 的时间，包括存储访问和向发送队列写入响应
+	metricBizDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gochat_biz_duration_seconds",
+		Help:    "Packet handler latency by kind.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind"})
+	// 群消息只计一次
+	metricMsgsStored = metrics.NewCounter(prometheus.CounterOpts{
+		Name: "gochat_messages_stored_total",
+		Help: "Messages stored.",
+	})
+	// 每发送给一个 session 计一次，包括离线消息
+	metricMsgsDelivered = metrics.NewCounter(prometheus.CounterOpts{
+		Name: "gochat_messages_delivered_total",
+		Help: "Messages written to client sessions.",
+	})
+	metricAuthFailures = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "gochat_auth_failures_total",
+		Help: "Failed signins and token validations by error code.",
+	}, []string{"code"})
+	metricStoreDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gochat_store_query_duration_seconds",
+		Help:    "Store query latency by store and operation.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"store", "op"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	counter := func(name, help string, f func() int64) {
+		metrics.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 { return float64(f()) })
+	}
+	counter("gochat_dropped_pushes_total", "Pushes dropped because a session send queue was full.", hubStats.DroppedPushes.Load)
+	counter("gochat_dropped_messages_total", "Messages dropped because a session send queue was full.", hubStats.DroppedMsgs.Load)
+	counter("gochat_slow_disconnects_total", "Sessions disconnected as slow consumers.", hubStats.SlowDisconnects.Load)
+}
+
+// 导出 hub 事件队列和 push 队列中等待处理的事件数量，只在 main 中调用一次
+func registerQueueMetrics(eventChan chan<- event_i, pushChan chan<- *lib.Push) {
+	gauge := func(queue string, f func() int) {
+		metrics.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "gochat_push_queue_depth",
+			Help:        "Events waiting in the push hub queues.",
+			ConstLabels: prometheus.Labels{"queue": queue},
+		}, func() float64 { return float64(f()) })
+	}
+	gauge("event", func() int { return len(eventChan) })
+	gauge("push", func() int { return len(pushChan) })
+}
+
+// 记录认证失败，返回 code
+func authFailed(code lib.ErrCode) lib.ErrCode {
+	metricAuthFailures.WithLabelValues(strconv.Itoa(int(code))).Inc()
+	return code
+}
+
+// 记录 gorm 执行每条 sql 的时间
+func instrumentGorm(db *gorm.DB) error {
+	const startKey = "metrics:start"
+	store := db.Dialector.Name()
+
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(startKey, time.Now())
+	}
+	after := func(op string) func(*gorm.DB) {
+		observer := metricStoreDuration.WithLabelValues(store, op)
+		return func(tx *gorm.DB) {
+			if start, ok := tx.InstanceGet(startKey); ok {
+				observer.Observe(time.Since(start.(time.Time)).Seconds())
+			}
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 监听 c.MetricsAddr 并导出 prometheus 指标
+func serveMetrics(c *config_t) (*http.Server, error) {
+	ln, err := net.Listen("tcp", c.MetricsAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics listener stopped", "addr", c.MetricsAddr, "err", err)
+		}
+	}()
+	return srv, nil
+}
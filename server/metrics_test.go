@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/huoyijie/GoChat/lib"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 导出的指标包括存储查询延迟和认证失败次数
+func TestMetrics(t *testing.T) {
+	storage, err := newSQLiteStore(filepath.Join(t.TempDir(), "server.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+
+	if _, err := storage.GetAccountByUN("nobody"); err == nil {
+		t.Fatal("unknown username should fail")
+	}
+	authFailed(lib.Err_Bcrypt_Compare)
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`gochat_store_query_duration_seconds_count{op="query",store="sqlite"}`,
+		`gochat_auth_failures_total{code="` + strconv.Itoa(int(lib.Err_Bcrypt_Compare)) + `"}`,
+		"gochat_sessions ",
+		"gochat_dropped_pushes_total ",
+		"go_goroutines ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
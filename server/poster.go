@@ -7,10 +7,10 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-// 转换同步响应类型
+// 转换同步响应类型。ErrRes 用于响应不支持的请求，所有响应类型的第一个字段都是 code，客户端可以按照原本的响应类型解析
 func syncResponseToKind(m proto.Message) (kind lib.PackKind, err error) {
 	switch m.(type) {
-	case *lib.TokenRes, *lib.UsersRes, *lib.SignoutRes:
+	case *lib.TokenRes, *lib.UsersRes, *lib.SignoutRes, *lib.GroupRes, *lib.GroupsRes, *lib.HistoryRes, *lib.MsgRes, *lib.KeyRes, *lib.SessionsRes, *lib.RevokeRes, *lib.HelloRes, *lib.ErrRes:
 		kind = lib.PackKind_RES
 	default:
 		err = errors.New("invalid kind of packet")
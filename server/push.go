@@ -1,16 +1,24 @@
 package main
 
 import (
+	"log/slog"
+	"sync/atomic"
+
 	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
 )
 
 // 定义事件接口
 type event_i interface{}
 
-// 上线事件
+// 上线事件，hub 关闭 kick 时表示需要断开连接
 type e_online_t struct {
-	sid uint64
-	c   chan<- *lib.Push
+	sid      uint64
+	id       uint64
+	tid      uint64
+	username string
+	c        chan<- proto.Message
+	kick     chan struct{}
 }
 
 // 下线事件
@@ -18,22 +26,374 @@ type e_offline_t struct {
 	sid uint64
 }
 
-// 维护客户端 sessions，接收并处理客户端上下线事件，接收并转发 push 到客户端
-func handlePush(eventChan <-chan event_i, pushChan <-chan *lib.Push) {
-	sessions := make(map[uint64]chan<- *lib.Push)
+// 新消息事件，转发给接收者所有在线的 session
+type e_msg_t struct {
+	msg *lib.Msg
+}
+
+// 同步事件，把用户自己发送的消息转发给该用户除 sid 之外的其他在线 session
+type e_sync_t struct {
+	sid uint64
+	msg *lib.Msg
+}
+
+// 定向 push 事件，只转发给 to 用户所有在线的 session
+type e_push_t struct {
+	to   string
+	push *lib.Push
+}
+
+// 撤销 session 事件，断开用户使用这些 session 登录的连接
+type e_revoke_t struct {
+	username string
+	tids     []uint64
+}
+
+// 服务器关闭事件，之后不再发送上下线提醒
+type e_shutdown_t struct{}
+
+// 健康检查事件，hub 处理到该事件时关闭 done
+type e_probe_t struct {
+	done chan struct{}
+}
+
+// 查询已登录 session 事件，hub 通过 reply 返回 sid -> session 的副本，reply 的缓冲区长度必须为 1
+type e_sessions_t struct {
+	reply chan<- map[uint64]session_t
+}
+
+// 在线 session
+type session_t struct {
+	id       uint64
+	tid      uint64
+	username string
+	c        chan<- proto.Message
+	kick     chan struct{}
+}
+
+// hub 统计计数
+type hub_stats_t struct {
+	// 因发送队列已满而丢弃的 push 数量
+	DroppedPushes atomic.Int64
+	// 因发送队列已满而丢弃的消息数量，消息仍保存在存储中
+	DroppedMsgs atomic.Int64
+	// 因发送队列已满而断开的连接数量
+	SlowDisconnects atomic.Int64
+}
+
+var hubStats hub_stats_t
+
+// 到其他节点的出站连接
+type peer_t struct {
+	c    chan<- *lib.ClusterEvent
+	kick chan struct{}
+}
+
+// 维护客户端 sessions，接收并处理客户端上下线事件，接收并转发 push 和新消息到客户端
+//
+// 一个用户可以同时从多个设备登录，用户的第一个 session 上线时才更新在线状态并发送上下线提醒，最后一个 session 下线时才发送下线提醒
+//
+// 向 session 发送 push 或消息时不会阻塞，发送队列已满的慢速连接按照 conf.SlowConsumer 策略处理，不会影响其他 session
+//
+// 集群模式下，本地用户上下线时通知其他节点，新消息和 push 同时转发给接收者所在的节点。用户在整个集群中第一个 session 上线或最后一个 session 下线时才发送上下线提醒
+func handlePush(eventChan <-chan event_i, pushChan <-chan *lib.Push, storage store_i) {
+	sessions := make(map[uint64]*session_t)
+	// username -> sids
+	users := make(map[string]map[uint64]bool)
+	// 服务器正在关闭
+	var closing bool
+
+	// 其他节点: node -> 出站连接
+	peers := make(map[int64]*peer_t)
+	// 其他节点上在线的用户: username -> nodes
+	remote := make(map[string]map[int64]bool)
+	// 其他节点上在线用户的帐号 id，节点断开时更新在线状态
+	remoteIds := make(map[string]uint64)
+	// node -> 当前入站连接 id
+	inbound := make(map[int64]uint64)
+
+	var offline func(sid uint64)
+
+	// 断开连接，并立即下线该 session
+	kick := func(sid uint64) {
+		s, found := sessions[sid]
+		if !found {
+			return
+		}
+		close(s.kick)
+		offline(sid)
+	}
+
+	// 向 session 发送 push 或消息，发送队列已满时丢弃，返回是否发送成功
+	deliver := func(sid uint64, m proto.Message) bool {
+		s, found := sessions[sid]
+		if !found {
+			return false
+		}
+
+		select {
+		case s.c <- m:
+			return true
+		default:
+		}
+
+		if _, ok := m.(*lib.Msg); ok {
+			hubStats.DroppedMsgs.Add(1)
+		} else {
+			hubStats.DroppedPushes.Add(1)
+		}
+		if conf.SlowConsumer == SLOW_CONSUMER_DISCONNECT {
+			hubStats.SlowDisconnects.Add(1)
+			slog.Warn("slow consumer disconnected", "sid", sid, "acc_id", s.id, "username", s.username)
+			kick(sid)
+		}
+		return false
+	}
+
+	broadcast := func(push *lib.Push) {
+		for sid := range sessions {
+			deliver(sid, push)
+		}
+	}
+
+	// 撤销用户使用这些 session 登录的连接
+	revoke := func(username string, tids []uint64) {
+		for sid := range users[username] {
+			for _, tid := range tids {
+				// 无法发送错误时直接断开连接
+				if s, found := sessions[sid]; found && s.tid == tid && !deliver(sid, &lib.ErrRes{Code: lib.Err_Token_Revoked.Val()}) {
+					kick(sid)
+				}
+			}
+		}
+	}
+
+	// 向其他节点发送事件，发送队列已满时断开连接，重新连接后会重新同步在线用户
+	forward := func(node int64, ev *lib.ClusterEvent) {
+		p, found := peers[node]
+		if !found {
+			return
+		}
+		select {
+		case p.c <- ev:
+		default:
+			slog.Warn("cluster peer too slow", "node", node)
+			close(p.kick)
+			delete(peers, node)
+		}
+	}
+
+	// 转发给 username 所在的其他节点
+	forwardTo := func(username string, ev *lib.ClusterEvent) {
+		for node := range remote[username] {
+			forward(node, ev)
+		}
+	}
+
+	forwardAll := func(ev *lib.ClusterEvent) {
+		for node := range peers {
+			forward(node, ev)
+		}
+	}
+
+	// 更新用户在线状态，并向所有 session 发送上下线提醒
+	presence := func(id uint64, username string, kind lib.OnlineKind) {
+		if err := storage.UpdateOnline(id, kind == lib.OnlineKind_ON); err != nil {
+			slog.Error("update online", "acc_id", id, "username", username, "err", err)
+		}
+		if closing {
+			return
+		}
+
+		bytes, err := lib.Marshal(&lib.Online{Kind: kind, Username: username})
+		lib.FatalNotNil(err)
+		broadcast(&lib.Push{Kind: lib.PushKind_ONLINE, Data: bytes})
+	}
+
+	offline = func(sid uint64) {
+		s, found := sessions[sid]
+		if !found {
+			return
+		}
+		delete(users[s.username], sid)
+		delete(sessions, sid)
+		if len(users[s.username]) == 0 {
+			delete(users, s.username)
+			metricUsers.Set(float64(len(users)))
+			forwardAll(&lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_OFFLINE, Username: s.username, Id: s.id})
+			if len(remote[s.username]) == 0 {
+				presence(s.id, s.username, lib.OnlineKind_OFF)
+			}
+		}
+	}
+
+	// 其他节点已断开，该节点上的用户如果在其他地方都不在线，则设置为离线
+	dropNode := func(node int64) {
+		delete(inbound, node)
+		for username, nodes := range remote {
+			if !nodes[node] {
+				continue
+			}
+			delete(nodes, node)
+			if len(nodes) > 0 {
+				continue
+			}
+			delete(remote, username)
+			if users[username] == nil {
+				presence(remoteIds[username], username, lib.OnlineKind_OFF)
+			}
+			delete(remoteIds, username)
+		}
+	}
+
+	// 处理其他节点发送的事件，只转发给本地 session
+	handleRemote := func(node int64, ev *lib.ClusterEvent) {
+		switch ev.Kind {
+		case lib.ClusterKind_CLUSTER_ONLINE:
+			online := users[ev.Username] != nil || len(remote[ev.Username]) > 0
+			if remote[ev.Username] == nil {
+				remote[ev.Username] = make(map[int64]bool)
+			}
+			remote[ev.Username][node] = true
+			remoteIds[ev.Username] = ev.Id
+			if !online {
+				presence(ev.Id, ev.Username, lib.OnlineKind_ON)
+			}
+		case lib.ClusterKind_CLUSTER_OFFLINE:
+			if !remote[ev.Username][node] {
+				return
+			}
+			delete(remote[ev.Username], node)
+			if len(remote[ev.Username]) == 0 {
+				delete(remote, ev.Username)
+				delete(remoteIds, ev.Username)
+				if users[ev.Username] == nil {
+					presence(ev.Id, ev.Username, lib.OnlineKind_OFF)
+				}
+			}
+		case lib.ClusterKind_CLUSTER_MSG:
+			for sid := range users[ev.Msg.To] {
+				deliver(sid, ev.Msg)
+			}
+		case lib.ClusterKind_CLUSTER_SYNC:
+			for sid := range users[ev.Msg.From] {
+				deliver(sid, ev.Msg)
+			}
+		case lib.ClusterKind_CLUSTER_PUSH:
+			for sid := range users[ev.Username] {
+				deliver(sid, ev.Push)
+			}
+		case lib.ClusterKind_CLUSTER_BROADCAST:
+			broadcast(ev.Push)
+		case lib.ClusterKind_CLUSTER_REVOKE:
+			revoke(ev.Username, ev.Tids)
+		}
+	}
+
 	for {
 		select {
 		case e := <-eventChan:
 			switch e := e.(type) {
 			case *e_online_t:
-				sessions[e.sid] = e.c
+				// 同一连接重新登录时，先下线之前的 session
+				offline(e.sid)
+
+				s := &session_t{e.id, e.tid, e.username, e.c, e.kick}
+				sessions[e.sid] = s
+				if users[e.username] == nil {
+					users[e.username] = make(map[uint64]bool)
+					metricUsers.Set(float64(len(users)))
+					forwardAll(&lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_ONLINE, Username: e.username, Id: e.id})
+					if len(remote[e.username]) == 0 {
+						presence(e.id, e.username, lib.OnlineKind_ON)
+					}
+				}
+				users[e.username][e.sid] = true
 			case *e_offline_t:
-				delete(sessions, e.sid)
+				offline(e.sid)
+			case *e_msg_t:
+				for sid := range users[e.msg.To] {
+					deliver(sid, e.msg)
+				}
+				forwardTo(e.msg.To, &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_MSG, Msg: e.msg})
+			case *e_sync_t:
+				for sid := range users[e.msg.From] {
+					if sid != e.sid {
+						deliver(sid, e.msg)
+					}
+				}
+				forwardTo(e.msg.From, &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_SYNC, Msg: e.msg})
+			case *e_push_t:
+				for sid := range users[e.to] {
+					deliver(sid, e.push)
+				}
+				forwardTo(e.to, &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_PUSH, Username: e.to, Push: e.push})
+			case *e_shutdown_t:
+				closing = true
+			case *e_probe_t:
+				close(e.done)
+			case *e_sessions_t:
+				snapshot := make(map[uint64]session_t, len(sessions))
+				for sid, s := range sessions {
+					snapshot[sid] = *s
+				}
+				e.reply <- snapshot
+			case *e_revoke_t:
+				revoke(e.username, e.tids)
+				forwardTo(e.username, &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_REVOKE, Username: e.username, Tids: e.tids})
+			case *e_peer_t:
+				if e.c == nil {
+					if p, found := peers[e.node]; found && p.kick == e.kick {
+						delete(peers, e.node)
+					}
+					break
+				}
+				// 先发送当前节点所有在线用户
+				snapshot := make([]*lib.ClusterEvent, 0, len(users))
+				for username, sids := range users {
+					for sid := range sids {
+						snapshot = append(snapshot, &lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_ONLINE, Username: username, Id: sessions[sid].id})
+						break
+					}
+				}
+				e.snapshot <- snapshot
+				peers[e.node] = &peer_t{e.c, e.kick}
+			case *e_remote_t:
+				switch {
+				case e.ev == nil:
+					if inbound[e.node] == e.conn {
+						dropNode(e.node)
+					}
+				case e.ev.Kind == lib.ClusterKind_CLUSTER_HELLO:
+					// 节点重新连接后会重新发送所有在线用户
+					dropNode(e.node)
+					inbound[e.node] = e.conn
+				case inbound[e.node] == e.conn:
+					handleRemote(e.node, e.ev)
+				}
 			}
 		case push := <-pushChan:
-			for _, c := range sessions {
-				c <- push
-			}
+			broadcast(push)
+			forwardAll(&lib.ClusterEvent{Kind: lib.ClusterKind_CLUSTER_BROADCAST, Push: push})
+		}
+	}
+}
+
+// 向消息发送者发送回执 push，peer 为已收到或已读消息的用户。只有单聊消息有回执
+func sendReceipts(eventChan chan<- event_i, status lib.MsgStatus, peer string, msgList []Message) error {
+	ids := make(map[string][]int64)
+	for i := range msgList {
+		if msgList[i].Group == 0 {
+			ids[msgList[i].From] = append(ids[msgList[i].From], msgList[i].Id)
+		}
+	}
+
+	for from := range ids {
+		bytes, err := lib.Marshal(&lib.Receipt{Status: status, Peer: peer, Ids: ids[from]})
+		if err != nil {
+			return err
 		}
+		eventChan <- &e_push_t{from, &lib.Push{Kind: lib.PushKind_MSG_RECEIPT, Data: bytes}}
 	}
+	return nil
 }
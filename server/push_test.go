@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+// 统计 storage 执行的 sql 数量
+func countQueries(b *testing.B, storage *gorm_store_t) *int64 {
+	var count int64
+	inc := func(*gorm.DB) { atomic.AddInt64(&count, 1) }
+	cb := storage.db.Callback()
+	lib.FatalNotNil(cb.Create().After("gorm:create").Register("count:create", inc))
+	lib.FatalNotNil(cb.Query().After("gorm:query").Register("count:query", inc))
+	lib.FatalNotNil(cb.Update().After("gorm:update").Register("count:update", inc))
+	lib.FatalNotNil(cb.Delete().After("gorm:delete").Register("count:delete", inc))
+	lib.FatalNotNil(cb.Row().After("gorm:row").Register("count:row", inc))
+	lib.FatalNotNil(cb.Raw().After("gorm:raw").Register("count:raw", inc))
+	return &count
+}
+
+// 启动一个已登录的 session，返回客户端连接
+func newSession(sid uint64, username string, eventChan chan<- event_i, storage store_i) (server, client net.Conn) {
+	server, client = net.Pipe()
+	packChan := make(chan *lib.Packet, conf.SessionBuffer)
+	c := make(chan proto.Message, conf.SessionBuffer)
+	kick := make(chan struct{})
+	var account atomic.Pointer[conn_account_t]
+	account.Store(&conn_account_t{sid, username})
+	go func() {
+		sendTo(server, packChan, c, nil, kick, eventChan, &account, storage, newConnLog(sid, "pipe", &account))
+		server.Close()
+	}()
+	eventChan <- &e_online_t{sid, sid, 0, username, c, kick}
+	return
+}
+
+// 启动一个空闲 session，客户端丢弃收到的所有数据
+func idleSession(sid uint64, username string, eventChan chan<- event_i, storage store_i) net.Conn {
+	server, client := newSession(sid, username, eventChan, storage)
+	go io.Copy(io.Discard, client)
+	return server
+}
+
+// 等待 username 的未读消息全部转发完成，返回执行的查询次数
+func waitDelivered(storage *gorm_store_t, username string) (polls int64) {
+	for {
+		var unread int64
+		storage.db.Model(&Message{}).Where("`to` = ? AND delivered = ?", username, false).Count(&unread)
+		polls++
+		if unread == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 新消息直接转发到接收者 session，数据库负载只与消息数量有关，不随空闲 session 数量增长
+func BenchmarkMsgDelivery(b *testing.B) {
+	for _, idle := range []int{0, 100, 1000} {
+		b.Run(fmt.Sprintf("idle=%d", idle), func(b *testing.B) {
+			storage, err := newSQLiteStore(filepath.Join(b.TempDir(), "server.db"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			node, err := snowflake.NewNode(1)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			// 队列长度按 b.N 设置，统计的是转发开销而不是发送队列满时的丢弃策略
+			eventChan := make(chan event_i, b.N+idle+1)
+			pushChan := make(chan *lib.Push, 1024)
+			go handlePush(eventChan, pushChan, storage)
+
+			var sid uint64
+			for i := 0; i < idle; i++ {
+				sid++
+				defer idleSession(sid, fmt.Sprintf("idle%d", i), eventChan, storage).Close()
+			}
+			sid++
+			buffer := conf.SessionBuffer
+			conf.SessionBuffer = b.N
+			bob, client := newSession(sid, "bob", eventChan, storage)
+			conf.SessionBuffer = buffer
+			defer bob.Close()
+			var received int64
+			done := make(chan struct{})
+			go func() {
+				scanner := bufio.NewScanner(client)
+				scanner.Split(lib.SplitFunc)
+				for scanner.Scan() {
+					// 只统计新消息，忽略上下线提醒
+					pack := &lib.Packet{}
+					if err := lib.Unmarshal(scanner.Bytes(), pack); err == nil && pack.Kind == lib.PackKind_MSG {
+						if atomic.AddInt64(&received, 1) == int64(b.N) {
+							close(done)
+						}
+					}
+				}
+			}()
+
+			// 等待上线事件处理完成，上线时更新在线状态的查询不计入统计
+			for len(eventChan) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(100 * time.Millisecond)
+			queries := countQueries(b, storage)
+
+			// 空闲 session 不产生数据库访问
+			time.Sleep(200 * time.Millisecond)
+			idleQueries := atomic.LoadInt64(queries)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				message := &Message{Id: int64(node.Generate()), From: "alice", To: "bob", Data: []byte("hello")}
+				if err := storage.NewMsg(message); err != nil {
+					b.Fatal(err)
+				}
+				eventChan <- &e_msg_t{message.toLibMsg()}
+			}
+			// 等待所有消息转发完成
+			<-done
+			polls := waitDelivered(storage, "bob")
+			b.StopTimer()
+
+			b.ReportMetric(float64(idleQueries), "idle-queries")
+			b.ReportMetric(float64(atomic.LoadInt64(queries)-idleQueries-polls)/float64(b.N), "queries/op")
+		})
+	}
+}
+
+// 客户端收到上线提醒时回调 seen
+func readOnline(client net.Conn, seen func(username string)) {
+	scanner := bufio.NewScanner(client)
+	scanner.Split(lib.SplitFunc)
+	for scanner.Scan() {
+		pack := &lib.Packet{}
+		push := &lib.Push{}
+		online := &lib.Online{}
+		if lib.Unmarshal(scanner.Bytes(), pack) != nil || pack.Kind != lib.PackKind_PUSH ||
+			lib.Unmarshal(pack.Data, push) != nil || lib.Unmarshal(push.Data, online) != nil {
+			continue
+		}
+		seen(online.Username)
+	}
+}
+
+// 一个客户端不再读取数据，其他 session 仍能及时收到所有 push，慢速连接按策略丢弃或断开
+func TestSlowConsumer(t *testing.T) {
+	const (
+		healthy = 3
+		pushes  = 100
+	)
+
+	for _, policy := range []string{SLOW_CONSUMER_DISCONNECT, SLOW_CONSUMER_DROP} {
+		t.Run(policy, func(t *testing.T) {
+			defer func(c *config_t) { conf = c }(conf)
+			conf = defaultConfig()
+			conf.SessionBuffer = 16
+			// 写入超时足够长，慢速连接只能由 hub 断开
+			conf.WriteTimeout = time.Minute
+			conf.SlowConsumer = policy
+
+			storage := newMemoryStore()
+
+			eventChan := make(chan event_i, conf.EventBuffer)
+			pushChan := make(chan *lib.Push, conf.EventBuffer)
+			go handlePush(eventChan, pushChan, storage)
+
+			counts := make([]int64, healthy)
+			ready := make(chan struct{}, healthy)
+			for i := 0; i < healthy; i++ {
+				server, client := newSession(uint64(i+1), fmt.Sprintf("healthy%d", i), eventChan, storage)
+				defer server.Close()
+				count := &counts[i]
+				go readOnline(client, func(username string) {
+					switch username {
+					case "stuck":
+						ready <- struct{}{}
+					case "marker":
+						atomic.AddInt64(count, 1)
+					}
+				})
+			}
+			// 客户端从不读取数据
+			stuck, stuckClient := newSession(healthy+1, "stuck", eventChan, storage)
+			defer stuck.Close()
+
+			// pushChan 与 eventChan 之间没有顺序，等所有 session 上线后再发送 push
+			for i := 0; i < healthy; i++ {
+				<-ready
+			}
+
+			droppedPushes := hubStats.DroppedPushes.Load()
+			slowDisconnects := hubStats.SlowDisconnects.Load()
+
+			bytes, err := lib.Marshal(&lib.Online{Kind: lib.OnlineKind_ON, Username: "marker"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n := int64(1); n <= pushes; n++ {
+				pushChan <- &lib.Push{Kind: lib.PushKind_ONLINE, Data: bytes}
+
+				// 每个健康 session 都要及时收到这条 push
+				deadline := time.Now().Add(2 * time.Second)
+				for i := range counts {
+					for atomic.LoadInt64(&counts[i]) < n {
+						if time.Now().After(deadline) {
+							t.Fatalf("healthy%d received %d/%d pushes", i, atomic.LoadInt64(&counts[i]), n)
+						}
+						time.Sleep(time.Millisecond)
+					}
+				}
+			}
+
+			if hubStats.DroppedPushes.Load() == droppedPushes {
+				t.Error("expected dropped pushes for the stuck session")
+			}
+
+			// 断开策略下慢速连接被关闭，客户端读取时返回连接关闭而不是超时
+			stuckClient.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = io.Copy(io.Discard, stuckClient)
+			timeout := false
+			if err, ok := err.(net.Error); ok && err.Timeout() {
+				timeout = true
+			}
+			switch policy {
+			case SLOW_CONSUMER_DISCONNECT:
+				if hubStats.SlowDisconnects.Load() != slowDisconnects+1 {
+					t.Errorf("slow disconnects = %d, want %d", hubStats.SlowDisconnects.Load(), slowDisconnects+1)
+				}
+				if timeout {
+					t.Error("stuck session was not disconnected")
+				}
+			case SLOW_CONSUMER_DROP:
+				if hubStats.SlowDisconnects.Load() != slowDisconnects {
+					t.Error("stuck session should not be disconnected with drop policy")
+				}
+				if !timeout {
+					t.Errorf("stuck session closed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 内存存储，与 gorm_store_t 行为相同，所有数据在进程退出后丢失。返回的都是副本，调用方修改不会影响存储
+type memory_store_t struct {
+	mu sync.Mutex
+
+	accounts map[uint64]*Account
+	// 用户名到帐号 id
+	usernames map[string]uint64
+	sessions  map[uint64]*Session
+	// 按接收者分组的消息，to -> id -> 消息
+	inbox   map[string]map[int64]*Message
+	groups  map[uint64]*Group
+	members map[uint64]map[string]struct{}
+	nodes   map[int64]*Node
+
+	// 上一个分配的 id
+	accountId, sessionId, groupId uint64
+}
+
+func newMemoryStore() *memory_store_t {
+	return &memory_store_t{
+		accounts:  make(map[uint64]*Account),
+		usernames: make(map[string]uint64),
+		sessions:  make(map[uint64]*Session),
+		inbox:     make(map[string]map[int64]*Message),
+		groups:    make(map[uint64]*Group),
+		members:   make(map[uint64]map[string]struct{}),
+		nodes:     make(map[int64]*Node),
+	}
+}
+
+func (s *memory_store_t) NewAccount(account *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.usernames[account.Username]; found {
+		return errors.New("username already exists")
+	}
+
+	s.accountId++
+	account.Id = s.accountId
+	a := *account
+	s.accounts[a.Id] = &a
+	s.usernames[a.Username] = a.Id
+	return nil
+}
+
+func (s *memory_store_t) GetAccountById(id uint64) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, found := s.accounts[id]
+	if !found {
+		return nil, errNotFound
+	}
+	account := *a
+	return &account, nil
+}
+
+func (s *memory_store_t) GetAccountByUN(username string) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, found := s.accounts[s.usernames[username]]
+	if !found {
+		return nil, errNotFound
+	}
+	account := *a
+	return &account, nil
+}
+
+func (s *memory_store_t) UpdatePublicKey(id uint64, key, backup []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, found := s.accounts[id]; found {
+		a.PublicKey, a.KeyBackup = key, backup
+	}
+	return nil
+}
+
+func (s *memory_store_t) GetUsers(self string) ([]*lib.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]*lib.User, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		if a.Username != self {
+			users = append(users, &lib.User{Username: a.Username, Online: a.Online, Bot: a.Bot})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+func (s *memory_store_t) UpdateOnline(id uint64, online bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, found := s.accounts[id]; found {
+		a.Online = online
+	}
+	return nil
+}
+
+func (s *memory_store_t) ResetOnline() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		a.Online = false
+	}
+	return nil
+}
+
+func (s *memory_store_t) NewSession(accountId uint64) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessionId++
+	now := time.Now()
+	session := &Session{Id: s.sessionId, AccountId: accountId, CreatedAt: now, UpdatedAt: now}
+	sess := *session
+	s.sessions[sess.Id] = &sess
+	return session, nil
+}
+
+func (s *memory_store_t) APISession(accountId uint64) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *Session
+	for _, sess := range s.sessions {
+		if sess.AccountId == accountId && sess.API && !sess.Revoked && (latest == nil || sess.Id > latest.Id) {
+			latest = sess
+		}
+	}
+	now := time.Now()
+	if latest == nil {
+		s.sessionId++
+		latest = &Session{Id: s.sessionId, AccountId: accountId, API: true, CreatedAt: now}
+		s.sessions[latest.Id] = latest
+	}
+	latest.UpdatedAt = now
+	session := *latest
+	return &session, nil
+}
+
+func (s *memory_store_t) GetSession(id uint64) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, found := s.sessions[id]
+	if !found || sess.Revoked {
+		return nil, errNotFound
+	}
+	session := *sess
+	return &session, nil
+}
+
+func (s *memory_store_t) RotateSession(session *Session, serial uint64) (ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, found := s.sessions[session.Id]
+	if !found || sess.Revoked || sess.Serial != serial {
+		return false, nil
+	}
+	sess.Serial = serial + 1
+	sess.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *memory_store_t) GetSessions(accountId uint64) (sessions []Session, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sess := range s.sessions {
+		if sess.AccountId == accountId && !sess.Revoked {
+			sessions = append(sessions, *sess)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Id < sessions[j].Id })
+	return
+}
+
+func (s *memory_store_t) RevokeSessions(accountId uint64, ids []uint64) (revoked []uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if sess, found := s.sessions[id]; found && sess.AccountId == accountId && !sess.Revoked {
+			sess.Revoked = true
+			sess.UpdatedAt = time.Now()
+			revoked = append(revoked, id)
+		}
+	}
+	sort.Slice(revoked, func(i, j int) bool { return revoked[i] < revoked[j] })
+	return
+}
+
+func (s *memory_store_t) PruneSessions(before time.Time) (n int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, sess := range s.sessions {
+		if sess.Revoked || sess.UpdatedAt.Before(before) {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+	return
+}
+
+// 写入一条消息，id 和 to 相同的消息已存在时返回 error。调用方需要持有锁
+func (s *memory_store_t) insertMsg(msg Message) error {
+	inbox, found := s.inbox[msg.To]
+	if !found {
+		inbox = make(map[int64]*Message)
+		s.inbox[msg.To] = inbox
+	}
+	if _, found := inbox[msg.Id]; found {
+		return errors.New("message already exists")
+	}
+	inbox[msg.Id] = &msg
+	return nil
+}
+
+func (s *memory_store_t) NewMsg(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insertMsg(*msg)
+}
+
+func (s *memory_store_t) NewGroupMsg(msg *Message, members []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 与数据库批量写入一样，任意一行冲突时都不写入
+	for _, member := range members {
+		if _, found := s.inbox[member][msg.Id]; found {
+			return errors.New("message already exists")
+		}
+	}
+	for _, member := range members {
+		m := *msg
+		m.To = member
+		m.Delivered = member == msg.From
+		m.Read = m.Delivered
+		if err := s.insertMsg(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 按 id 升序排序
+func sortMsgList(msgList []Message) {
+	sort.Slice(msgList, func(i, j int) bool { return msgList[i].Id < msgList[j].Id })
+}
+
+func (s *memory_store_t) GetMsgList(to string) (msgList []Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, msg := range s.inbox[to] {
+		if !msg.Delivered {
+			msg.Delivered = true
+			msgList = append(msgList, *msg)
+		}
+	}
+	sortMsgList(msgList)
+	return
+}
+
+func (s *memory_store_t) UpdateDelivered(id int64, to string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, found := s.inbox[to][id]
+	if !found || msg.Delivered {
+		return false, nil
+	}
+	msg.Delivered = true
+	return true, nil
+}
+
+func (s *memory_store_t) UpdateRead(ids []int64, to string) (msgList []Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		msg, found := s.inbox[to][id]
+		if !found || msg.Read || seen[id] {
+			continue
+		}
+		seen[id] = true
+		// 返回更新之前的消息
+		msgList = append(msgList, *msg)
+		msg.Delivered = true
+		msg.Read = true
+	}
+	sortMsgList(msgList)
+	return
+}
+
+func (s *memory_store_t) GetHistory(self, peer string, group uint64, cursor int64, limit int) (msgList []Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	add := func(msg *Message) {
+		if cursor == 0 || msg.Id < cursor {
+			msgList = append(msgList, *msg)
+		}
+	}
+	if group > 0 {
+		for _, msg := range s.inbox[self] {
+			if msg.Group == group {
+				add(msg)
+			}
+		}
+	} else {
+		for _, msg := range s.inbox[peer] {
+			if msg.Group == 0 && msg.From == self {
+				add(msg)
+			}
+		}
+		// 与自己的单聊只统计一次
+		if peer != self {
+			for _, msg := range s.inbox[self] {
+				if msg.Group == 0 && msg.From == peer {
+					add(msg)
+				}
+			}
+		}
+	}
+
+	// 最近 limit 条消息，按 id 升序返回
+	sortMsgList(msgList)
+	if limit >= 0 && len(msgList) > limit {
+		msgList = msgList[len(msgList)-limit:]
+	}
+	return
+}
+
+// 返回已注册的用户名，调用方需要持有锁
+func (s *memory_store_t) existingUsernames(usernames []string) (existing []string) {
+	for _, username := range usernames {
+		if _, found := s.usernames[username]; found {
+			existing = append(existing, username)
+		}
+	}
+	return
+}
+
+// 调用方需要持有锁
+func (s *memory_store_t) addGroupMembers(id uint64, usernames []string) {
+	members, found := s.members[id]
+	if !found {
+		members = make(map[string]struct{})
+		s.members[id] = members
+	}
+	for _, username := range usernames {
+		members[username] = struct{}{}
+	}
+}
+
+// 按用户名排序返回群成员，调用方需要持有锁
+func (s *memory_store_t) groupMembers(id uint64) []string {
+	members := make([]string, 0, len(s.members[id]))
+	for username := range s.members[id] {
+		members = append(members, username)
+	}
+	sort.Strings(members)
+	return members
+}
+
+func (s *memory_store_t) NewGroup(group *Group, members []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groupId++
+	group.Id = s.groupId
+	g := *group
+	s.groups[g.Id] = &g
+	s.addGroupMembers(g.Id, s.existingUsernames(append(members, g.Owner)))
+	return nil
+}
+
+func (s *memory_store_t) GetGroup(id uint64) (*Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, found := s.groups[id]
+	if !found {
+		return nil, errNotFound
+	}
+	group := *g
+	return &group, nil
+}
+
+func (s *memory_store_t) GetGroupMembers(id uint64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.groupMembers(id), nil
+}
+
+func (s *memory_store_t) IsGroupMember(id uint64, username string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, found := s.members[id][username]
+	return found, nil
+}
+
+func (s *memory_store_t) AddGroupMembers(id uint64, usernames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addGroupMembers(id, s.existingUsernames(usernames))
+	return nil
+}
+
+func (s *memory_store_t) RemoveGroupMembers(id uint64, usernames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, username := range usernames {
+		delete(s.members[id], username)
+	}
+
+	group, found := s.groups[id]
+	if !found {
+		return errNotFound
+	}
+
+	members := s.groupMembers(id)
+	if len(members) == 0 {
+		delete(s.groups, id)
+		delete(s.members, id)
+		return nil
+	}
+
+	if _, found := s.members[id][group.Owner]; !found {
+		group.Owner = members[0]
+	}
+	return nil
+}
+
+func (s *memory_store_t) GetGroups(username string) ([]*lib.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]*lib.Group, 0)
+	for id, members := range s.members {
+		if _, found := members[username]; !found {
+			continue
+		}
+		if g, found := s.groups[id]; found {
+			groups = append(groups, &lib.Group{Id: g.Id, Name: g.Name, Owner: g.Owner, Members: s.groupMembers(id)})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].Id < groups[j].Id
+	})
+	return groups, nil
+}
+
+func (s *memory_store_t) GetGroupInfo(id uint64) (*lib.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, found := s.groups[id]
+	if !found {
+		return nil, errNotFound
+	}
+	return &lib.Group{Id: g.Id, Name: g.Name, Owner: g.Owner, Members: s.groupMembers(id)}, nil
+}
+
+func (s *memory_store_t) RegisterNode(addr string, ttl time.Duration) (*Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]Node, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		nodes = append(nodes, *node)
+	}
+	now := time.Now().UTC()
+	id, _, err := pickNodeId(nodes, now.Add(-ttl))
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{Id: id, Addr: addr, Heartbeat: now}
+	s.nodes[id] = node
+	copied := *node
+	return &copied, nil
+}
+
+func (s *memory_store_t) HeartbeatNode(node *Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, found := s.nodes[node.Id]
+	if !found || n.Addr != node.Addr {
+		return errNodeLost
+	}
+	n.Heartbeat = time.Now().UTC()
+	node.Heartbeat = n.Heartbeat
+	return nil
+}
+
+func (s *memory_store_t) GetNodes(ttl time.Duration) (nodes []Node, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := time.Now().UTC().Add(-ttl)
+	for _, node := range s.nodes {
+		if !node.Heartbeat.Before(expired) {
+			nodes = append(nodes, *node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+	return
+}
+
+func (s *memory_store_t) RemoveNode(node *Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n, found := s.nodes[node.Id]; found && n.Addr == node.Addr {
+		delete(s.nodes, node.Id)
+	}
+	return nil
+}
+
+func (s *memory_store_t) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *memory_store_t) Close() error {
+	return nil
+}
+
+var _ store_i = (*memory_store_t)(nil)
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 存储类型
+const (
+	// sqlite 文件，路径为 db_path
+	STORE_SQLITE = "sqlite"
+	// postgres 数据库，连接串为 db_dsn
+	STORE_POSTGRES = "postgres"
+	// 内存存储，重启后数据丢失，用于测试和开发
+	STORE_MEMORY = "memory"
+)
+
+var (
+	// 记录不存在
+	errNotFound = errors.New("record not found")
+	// 节点 id 已经被其他节点接管
+	errNodeLost = errors.New("cluster node id lost")
+	// 所有节点 id 都在使用中
+	errNoNodeId = errors.New("no free cluster node id")
+)
+
+// 服务器存储接口，biz 和连接处理逻辑只依赖该接口。查询不到记录时返回 error
+type store_i interface {
+	// 创建帐号，用户名已存在时返回 error，成功后设置 account.Id
+	NewAccount(account *Account) error
+	GetAccountById(id uint64) (*Account, error)
+	GetAccountByUN(username string) (*Account, error)
+	// 发布端到端加密公钥和加密后的私钥备份
+	UpdatePublicKey(id uint64, key, backup []byte) error
+	// 按用户名排序返回除 self 之外的所有用户
+	GetUsers(self string) ([]*lib.User, error)
+
+	// 更新用户在线状态
+	UpdateOnline(id uint64, online bool) error
+	// 把所有用户设置为离线，服务器启动和关闭时调用
+	ResetOnline() error
+
+	// 创建登录 session
+	NewSession(accountId uint64) (*Session, error)
+	// 查询用户最新的未撤销 HTTP API session 并更新刷新时间，没有时创建。HTTP API 每次登录复用同一个 session
+	APISession(accountId uint64) (*Session, error)
+	// 查询未撤销的 session
+	GetSession(id uint64) (*Session, error)
+	// 刷新 session，只有序号与 serial 相同时才会成功，ok 为 false 表示 token 已经被刷新过
+	RotateSession(session *Session, serial uint64) (ok bool, err error)
+	// 按 id 排序返回用户所有未撤销的 session
+	GetSessions(accountId uint64) ([]Session, error)
+	// 撤销用户的 session，返回撤销成功的 session id 列表
+	RevokeSessions(accountId uint64, ids []uint64) ([]uint64, error)
+	// 删除已撤销或者 before 之后没有刷新过的 session，返回删除的数量
+	PruneSessions(before time.Time) (int64, error)
+
+	// 存储单聊消息
+	NewMsg(msg *Message) error
+	// 群消息扩散写，为每个群成员存储一行消息。发送者的副本标记为已转发和已读，只用于查询历史消息
+	NewGroupMsg(msg *Message, members []string) error
+	// 获取发送给 to 的未转发消息，并标记为已转发
+	GetMsgList(to string) ([]Message, error)
+	// 标记为已转发。如果消息之前未转发，返回 true
+	UpdateDelivered(id int64, to string) (bool, error)
+	// 接收者 to 已读消息，标记为已读，返回之前未读的消息列表
+	UpdateRead(ids []int64, to string) ([]Message, error)
+	// 分页查询历史消息，返回 id 小于 cursor 的最近 limit 条消息（按 id 升序）。cursor 为 0 时从最新消息开始查询
+	//
+	// group 为 0 时查询 self 与 peer 之间的单聊消息，否则查询 self 收到的群消息副本
+	GetHistory(self, peer string, group uint64, cursor int64, limit int) ([]Message, error)
+
+	// 创建群组，创建者自动成为群主和群成员，忽略不存在的用户
+	NewGroup(group *Group, members []string) error
+	GetGroup(id uint64) (*Group, error)
+	// 按用户名排序返回群成员
+	GetGroupMembers(id uint64) ([]string, error)
+	// 查询 username 是否为群成员，查询失败时返回 error
+	IsGroupMember(id uint64, username string) (bool, error)
+	// 邀请用户加入群组，忽略不存在的用户
+	AddGroupMembers(id uint64, usernames []string) error
+	// 把用户移出群组。如果群主离开，群主转给剩余成员中的第一个；如果群成员为空，则解散群组
+	RemoveGroupMembers(id uint64, usernames []string) error
+	// 按群组名称排序返回 username 所在的群组列表
+	GetGroups(username string) ([]*lib.Group, error)
+	// 获取群组信息，包括群成员列表
+	GetGroupInfo(id uint64) (*lib.Group, error)
+
+	// 注册集群节点，分配未使用的最小节点 id，或者接管心跳已超过 ttl 的节点 id
+	RegisterNode(addr string, ttl time.Duration) (*Node, error)
+	// 刷新节点心跳，节点 id 已经被其他节点接管时返回 error
+	HeartbeatNode(node *Node) error
+	// 按 id 排序返回心跳未超过 ttl 的节点
+	GetNodes(ttl time.Duration) ([]Node, error)
+	// 注销节点
+	RemoveNode(node *Node) error
+
+	// 检查存储是否可以访问
+	Ping(ctx context.Context) error
+
+	Close() error
+}
+
+// 根据配置打开存储
+func openStore(c *config_t) (store_i, error) {
+	switch c.Store {
+	case STORE_SQLITE:
+		return newSQLiteStore(c.DBPath)
+	case STORE_POSTGRES:
+		return newPostgresStore(c.DBDSN)
+	case STORE_MEMORY:
+		return newMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store %s", c.Store)
+	}
+}
+
+type Account struct {
+	Id                uint64 `gorm:"primaryKey"`
+	Username          string `gorm:"uniqueIndex:uni_username"`
+	PasshashAndBcrypt string
+	Online            bool
+	// 机器人帐号
+	Bot bool
+	// 端到端加密公钥 (X25519)
+	PublicKey []byte
+	// 客户端使用密码派生密钥加密的身份私钥，服务器无法解密
+	KeyBackup []byte
+}
+
+// 登录 session，每次登录创建一个 session，刷新 token 时序号 Serial 加 1
+type Session struct {
+	Id        uint64 `gorm:"primaryKey"`
+	AccountId uint64 `gorm:"index"`
+	Serial    uint64
+	Revoked   bool
+	// HTTP API 登录创建的 session
+	API       bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// 消息按接收者存储，群消息会为每个群成员存储一行，这些行共享同一个消息 Id
+type Message struct {
+	Id    int64  `gorm:"primaryKey;autoIncrement:false"`
+	To    string `gorm:"primaryKey"`
+	Kind  int32
+	From  string
+	Group uint64 `gorm:"index"`
+	Data  []byte
+	// 已转发给接收者
+	Delivered bool
+	// 接收者已读
+	Read bool
+}
+
+// 转换为 lib.Msg
+func (m *Message) toLibMsg() *lib.Msg {
+	msg := &lib.Msg{
+		Id:    m.Id,
+		Kind:  lib.MsgKind(m.Kind),
+		From:  m.From,
+		To:    m.To,
+		Data:  m.Data,
+		Group: m.Group,
+	}
+	if m.Read {
+		msg.Status = lib.MsgStatus_READ
+	} else if m.Delivered {
+		msg.Status = lib.MsgStatus_DELIVERED
+	}
+	return msg
+}
+
+// 群组
+type Group struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Name  string
+	Owner string
+}
+
+// 群成员
+type GroupMember struct {
+	GroupId  uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Username string `gorm:"primaryKey"`
+}
+
+// 集群节点，节点 id 同时也是 snowflake 节点 id
+type Node struct {
+	Id int64 `gorm:"primaryKey;autoIncrement:false"`
+	// 集群内部通信地址
+	Addr string
+	// 最近一次心跳时间 (UTC)
+	Heartbeat time.Time
+}
+
+// snowflake 节点 id 最大值
+func maxNodeId() int64 {
+	return -1 ^ (-1 << snowflake.NodeBits)
+}
+
+// 从已注册的节点中选择节点 id: 优先选择未使用的最小 id，否则选择心跳早于 expired 的节点中最早的一个，takeover 为 true
+func pickNodeId(nodes []Node, expired time.Time) (id int64, takeover bool, err error) {
+	used := make(map[int64]bool, len(nodes))
+	for i := range nodes {
+		used[nodes[i].Id] = true
+	}
+	for id = 1; id <= maxNodeId(); id++ {
+		if !used[id] {
+			return
+		}
+	}
+
+	var oldest *Node
+	for i := range nodes {
+		if nodes[i].Heartbeat.Before(expired) && (oldest == nil || nodes[i].Heartbeat.Before(oldest.Heartbeat)) {
+			oldest = &nodes[i]
+		}
+	}
+	if oldest == nil {
+		return 0, false, errNoNodeId
+	}
+	return oldest.Id, true, nil
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 对所有存储实现执行相同的测试。设置 TEST_DB_DSN 时同时测试 postgres，如 docker run -e POSTGRES_PASSWORD=gochat -p 5432:5432 postgres
+func forEachStore(t *testing.T, test func(t *testing.T, storage store_i)) {
+	t.Run(STORE_MEMORY, func(t *testing.T) {
+		test(t, newMemoryStore())
+	})
+
+	t.Run(STORE_SQLITE, func(t *testing.T) {
+		storage, err := newSQLiteStore(filepath.Join(t.TempDir(), "server.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer storage.Close()
+		test(t, storage)
+	})
+
+	t.Run(STORE_POSTGRES, func(t *testing.T) {
+		dsn := os.Getenv("TEST_DB_DSN")
+		if len(dsn) == 0 {
+			t.Skip("TEST_DB_DSN not set")
+		}
+		storage, err := newPostgresStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer storage.Close()
+		// 清空之前测试留下的数据
+		for _, table := range []string{"accounts", "sessions", "messages", "groups", "group_members", "nodes"} {
+			if err := storage.db.Exec(`DELETE FROM "` + table + `"`).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		test(t, storage)
+	})
+}
+
+func msgIds(msgList []Message) []int64 {
+	ids := make([]int64, len(msgList))
+	for i := range msgList {
+		ids[i] = msgList[i].Id
+	}
+	return ids
+}
+
+func TestStoreAccounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		for _, username := range []string{"carol", "alice", "bob"} {
+			if err := storage.NewAccount(&Account{Username: username, Bot: username == "bob"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := storage.NewAccount(&Account{Username: "alice"}); err == nil {
+			t.Error("duplicate username should fail")
+		}
+
+		alice, err := storage.GetAccountByUN("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account, err := storage.GetAccountById(alice.Id); err != nil || account.Username != "alice" {
+			t.Errorf("GetAccountById = %v, %v", account, err)
+		}
+		if _, err := storage.GetAccountByUN("nobody"); err == nil {
+			t.Error("unknown username should fail")
+		}
+
+		if err := storage.UpdatePublicKey(alice.Id, []byte("key"), []byte("backup")); err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.UpdateOnline(alice.Id, true); err != nil {
+			t.Fatal(err)
+		}
+		if account, _ := storage.GetAccountById(alice.Id); string(account.PublicKey) != "key" || string(account.KeyBackup) != "backup" || !account.Online {
+			t.Errorf("account = %v", account)
+		}
+
+		users, err := storage.GetUsers("carol")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 || users[0].Username != "alice" || !users[0].Online || users[1].Username != "bob" || !users[1].Bot {
+			t.Errorf("users = %v", users)
+		}
+
+		if err := storage.ResetOnline(); err != nil {
+			t.Fatal(err)
+		}
+		if account, _ := storage.GetAccountById(alice.Id); account.Online {
+			t.Error("ResetOnline should set all accounts offline")
+		}
+	})
+}
+
+func TestStoreSessions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		var ids []uint64
+		for i := 0; i < 3; i++ {
+			session, err := storage.NewSession(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, session.Id)
+		}
+
+		session, err := storage.GetSession(ids[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := storage.RotateSession(session, 0); err != nil || !ok {
+			t.Fatalf("RotateSession = %v, %v", ok, err)
+		}
+		// token 已经被刷新过
+		if ok, _ := storage.RotateSession(session, 0); ok {
+			t.Error("rotating with a stale serial should fail")
+		}
+		if session, _ := storage.GetSession(ids[0]); session.Serial != 1 {
+			t.Errorf("serial = %d, want 1", session.Serial)
+		}
+
+		// 其他用户的 session 不会被撤销
+		revoked, err := storage.RevokeSessions(2, ids)
+		if err != nil || len(revoked) != 0 {
+			t.Errorf("RevokeSessions = %v, %v", revoked, err)
+		}
+		revoked, err = storage.RevokeSessions(1, ids[1:])
+		if err != nil || !reflect.DeepEqual(revoked, ids[1:]) {
+			t.Errorf("RevokeSessions = %v, %v", revoked, err)
+		}
+		if _, err := storage.GetSession(ids[1]); err == nil {
+			t.Error("revoked session should not be found")
+		}
+		if ok, _ := storage.RotateSession(&Session{Id: ids[2]}, 0); ok {
+			t.Error("revoked session should not rotate")
+		}
+
+		sessions, err := storage.GetSessions(1)
+		if err != nil || len(sessions) != 1 || sessions[0].Id != ids[0] {
+			t.Errorf("GetSessions = %v, %v", sessions, err)
+		}
+
+		// 删除已撤销的 session，未过期的 session 保留
+		if n, err := storage.PruneSessions(time.Now().Add(-time.Hour)); err != nil || n != 2 {
+			t.Errorf("PruneSessions = %d, %v, want 2", n, err)
+		}
+		if _, err := storage.GetSession(ids[0]); err != nil {
+			t.Errorf("GetSession = %v", err)
+		}
+		// 超过有效期没有刷新过的 session 被删除
+		if n, err := storage.PruneSessions(time.Now().Add(time.Second)); err != nil || n != 1 {
+			t.Errorf("PruneSessions = %d, %v, want 1", n, err)
+		}
+		if _, err := storage.GetSession(ids[0]); err == nil {
+			t.Error("expired session should be pruned")
+		}
+	})
+}
+
+// HTTP API 登录复用用户最新的未撤销 API session，撤销后创建新的 session
+func TestStoreAPISession(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		// 客户端登录创建的 session 不会被复用
+		if _, err := storage.NewSession(1); err != nil {
+			t.Fatal(err)
+		}
+		first, err := storage.APISession(1)
+		if err != nil || !first.API {
+			t.Fatalf("APISession = %v, %v", first, err)
+		}
+		again, err := storage.APISession(1)
+		if err != nil || again.Id != first.Id || again.UpdatedAt.Before(first.UpdatedAt) {
+			t.Errorf("APISession = %v, %v, want session %d", again, err, first.Id)
+		}
+		if other, err := storage.APISession(2); err != nil || other.Id == first.Id {
+			t.Errorf("APISession of another account = %v, %v", other, err)
+		}
+		if sessions, _ := storage.GetSessions(1); len(sessions) != 2 {
+			t.Errorf("GetSessions = %v, want 2 sessions", sessions)
+		}
+
+		if _, err := storage.RevokeSessions(1, []uint64{first.Id}); err != nil {
+			t.Fatal(err)
+		}
+		if next, err := storage.APISession(1); err != nil || next.Id == first.Id {
+			t.Errorf("APISession after revoke = %v, %v", next, err)
+		}
+	})
+}
+
+func TestStoreMessages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		msgs := []Message{
+			{Id: 1, From: "alice", To: "bob", Data: []byte("1")},
+			{Id: 2, From: "bob", To: "alice", Data: []byte("2")},
+			{Id: 3, From: "alice", To: "bob", Data: []byte("3")},
+			{Id: 4, From: "carol", To: "bob", Data: []byte("4")},
+		}
+		for i := range msgs {
+			if err := storage.NewMsg(&msgs[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := storage.NewMsg(&msgs[0]); err == nil {
+			t.Error("duplicate message should fail")
+		}
+		if err := storage.NewGroupMsg(&Message{Id: 5, From: "alice", Group: 9, Data: []byte("5")}, []string{"alice", "bob"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := storage.UpdateDelivered(1, "bob"); err != nil || !ok {
+			t.Errorf("UpdateDelivered = %v, %v", ok, err)
+		}
+		if ok, _ := storage.UpdateDelivered(1, "bob"); ok {
+			t.Error("message already delivered")
+		}
+
+		// 未转发的消息只返回一次，发送者的群消息副本已经标记为已转发
+		msgList, err := storage.GetMsgList("bob")
+		if err != nil || !reflect.DeepEqual(msgIds(msgList), []int64{3, 4, 5}) || !msgList[0].Delivered {
+			t.Errorf("GetMsgList = %v, %v", msgList, err)
+		}
+		if msgList, _ := storage.GetMsgList("bob"); len(msgList) != 0 {
+			t.Errorf("GetMsgList again = %v", msgList)
+		}
+		if msgList, _ := storage.GetMsgList("alice"); !reflect.DeepEqual(msgIds(msgList), []int64{2}) {
+			t.Errorf("GetMsgList alice = %v", msgIds(msgList))
+		}
+
+		// 只能标记发送给自己的消息
+		msgList, err = storage.UpdateRead([]int64{1, 2, 4, 4}, "bob")
+		if err != nil || !reflect.DeepEqual(msgIds(msgList), []int64{1, 4}) || msgList[0].Read {
+			t.Errorf("UpdateRead = %v, %v", msgList, err)
+		}
+		if msgList, _ := storage.UpdateRead([]int64{1}, "bob"); len(msgList) != 0 {
+			t.Errorf("UpdateRead again = %v", msgList)
+		}
+
+		history, err := storage.GetHistory("alice", "bob", 0, 0, 10)
+		if err != nil || !reflect.DeepEqual(msgIds(history), []int64{1, 2, 3}) || !history[0].Read {
+			t.Errorf("GetHistory = %v, %v", msgIds(history), err)
+		}
+		if history, _ := storage.GetHistory("bob", "alice", 0, 3, 1); !reflect.DeepEqual(msgIds(history), []int64{2}) {
+			t.Errorf("GetHistory cursor = %v", msgIds(history))
+		}
+		if history, _ := storage.GetHistory("bob", "", 9, 0, 10); !reflect.DeepEqual(msgIds(history), []int64{5}) {
+			t.Errorf("GetHistory group = %v", msgIds(history))
+		}
+	})
+}
+
+func TestStoreGroups(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		for _, username := range []string{"alice", "bob", "carol"} {
+			if err := storage.NewAccount(&Account{Username: username}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		group := &Group{Name: "ops", Owner: "alice"}
+		if err := storage.NewGroup(group, []string{"bob", "nobody"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.NewGroup(&Group{Name: "dev", Owner: "bob"}, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if members, err := storage.GetGroupMembers(group.Id); err != nil || !reflect.DeepEqual(members, []string{"alice", "bob"}) {
+			t.Errorf("GetGroupMembers = %v, %v", members, err)
+		}
+		if err := storage.AddGroupMembers(group.Id, []string{"carol", "bob", "nobody"}); err != nil {
+			t.Fatal(err)
+		}
+		if member, err := storage.IsGroupMember(group.Id, "carol"); err != nil || !member {
+			t.Errorf("IsGroupMember(carol) = %v, %v", member, err)
+		}
+		if member, err := storage.IsGroupMember(group.Id, "nobody"); err != nil || member {
+			t.Errorf("IsGroupMember(nobody) = %v, %v", member, err)
+		}
+
+		groups, err := storage.GetGroups("bob")
+		if err != nil || len(groups) != 2 || groups[0].Name != "dev" || groups[1].Name != "ops" {
+			t.Errorf("GetGroups = %v, %v", groups, err)
+		}
+
+		// 群主离开后转给剩余成员中的第一个
+		if err := storage.RemoveGroupMembers(group.Id, []string{"alice"}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := storage.GetGroupInfo(group.Id)
+		if err != nil || info.Owner != "bob" || !reflect.DeepEqual(info.Members, []string{"bob", "carol"}) {
+			t.Errorf("GetGroupInfo = %v, %v", info, err)
+		}
+
+		// 成员为空时解散群组
+		if err := storage.RemoveGroupMembers(group.Id, []string{"bob", "carol"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := storage.GetGroup(group.Id); err == nil {
+			t.Error("empty group should be deleted")
+		}
+		if err := storage.RemoveGroupMembers(group.Id, []string{"bob"}); err == nil {
+			t.Error("removing members from a deleted group should fail")
+		}
+	})
+}
+
+func TestStoreNodes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, storage store_i) {
+		var nodes []*Node
+		for _, addr := range []string{"10.0.0.1:7946", "10.0.0.2:7946", "10.0.0.3:7946"} {
+			node, err := storage.RegisterNode(addr, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes = append(nodes, node)
+		}
+		if nodes[0].Id != 1 || nodes[1].Id != 2 || nodes[2].Id != 3 {
+			t.Errorf("node ids = %d, %d, %d", nodes[0].Id, nodes[1].Id, nodes[2].Id)
+		}
+
+		// 注销后 id 可以被新节点使用，旧节点不能再刷新心跳
+		if err := storage.RemoveNode(nodes[1]); err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.HeartbeatNode(nodes[1]); err == nil {
+			t.Error("heartbeat of a removed node should fail")
+		}
+		node, err := storage.RegisterNode("10.0.0.4:7946", time.Minute)
+		if err != nil || node.Id != 2 {
+			t.Fatalf("RegisterNode = %v, %v", node, err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		if err := storage.HeartbeatNode(nodes[0]); err != nil {
+			t.Fatal(err)
+		}
+		alive, err := storage.GetNodes(5 * time.Millisecond)
+		if err != nil || len(alive) != 1 || alive[0].Id != 1 {
+			t.Errorf("GetNodes = %v, %v", alive, err)
+		}
+		if alive, _ := storage.GetNodes(time.Minute); len(alive) != 3 || alive[1].Addr != "10.0.0.4:7946" {
+			t.Errorf("GetNodes = %v", alive)
+		}
+	})
+}
+
+// 所有 id 都在使用中时接管心跳超时最早的节点
+func TestPickNodeId(t *testing.T) {
+	now := time.Now()
+	var nodes []Node
+	for id := int64(1); id <= maxNodeId(); id++ {
+		nodes = append(nodes, Node{Id: id, Heartbeat: now})
+	}
+	if _, _, err := pickNodeId(nodes, now.Add(-time.Minute)); err != errNoNodeId {
+		t.Errorf("err = %v, want %v", err, errNoNodeId)
+	}
+
+	nodes[7].Heartbeat = now.Add(-2 * time.Minute)
+	nodes[3].Heartbeat = now.Add(-3 * time.Minute)
+	if id, takeover, err := pickNodeId(nodes, now.Add(-time.Minute)); err != nil || !takeover || id != 4 {
+		t.Errorf("pickNodeId = %d, %v, %v", id, takeover, err)
+	}
+}
+
+// 旧版本的 messages 表主键只有 id，升级后群消息的多行可以共享同一个 id，旧消息保留
+func TestMigrateMessagePK(t *testing.T) {
+	for name, schema := range map[string][]string{
+		// 最初版本的表结构
+		"v1": {`CREATE TABLE messages (id integer PRIMARY KEY, kind integer, "from" text, "to" text, data blob, read numeric)`},
+		// AutoMigrate 已经添加了新字段，但没有修改主键
+		"automigrated": {
+			`CREATE TABLE messages (id integer PRIMARY KEY, kind integer, "from" text, "to" text, data blob, read numeric, "group" integer DEFAULT 0, delivered numeric DEFAULT false)`,
+			`CREATE INDEX idx_messages_group ON messages("group")`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "server.db")
+			old, err := newSQLiteStore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sqls := append([]string{`DROP TABLE messages`}, schema...)
+			sqls = append(sqls, `INSERT INTO messages (id, kind, "from", "to", data, read) VALUES (1, 0, 'alice', 'bob', 'hi', true), (2, 0, 'bob', 'alice', 'yo', false)`)
+			if name == "automigrated" {
+				sqls = append(sqls, `UPDATE messages SET delivered = read`)
+			}
+			for _, sql := range sqls {
+				if err := old.db.Exec(sql).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			old.Close()
+
+			storage, err := newSQLiteStore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer storage.Close()
+
+			if err := storage.NewGroupMsg(&Message{Id: 3, From: "alice", Group: 1, Data: []byte("all")}, []string{"alice", "bob", "carol"}); err != nil {
+				t.Fatal(err)
+			}
+			var msgList []Message
+			if err := storage.db.Order("id, \"to\"").Find(&msgList).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(msgList) != 5 {
+				t.Fatalf("messages = %v", msgList)
+			}
+			if m := msgList[0]; m.Id != 1 || m.To != "bob" || string(m.Data) != "hi" || !m.Read || !m.Delivered {
+				t.Errorf("migrated read message = %+v", m)
+			}
+			if m := msgList[1]; m.Id != 2 || m.Read || m.Delivered {
+				t.Errorf("migrated unread message = %+v", m)
+			}
+			if !storage.db.Migrator().HasIndex(&Message{}, "Group") {
+				t.Error("group index missing")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// 生成自签名证书，已存在时跳过
+func ensureDevCert(certFile, keyFile string, hosts []string) error {
+	if _, err := os.Stat(certFile); err == nil {
+		return nil
+	}
+
+	certPEM, keyPEM, err := lib.NewSelfSignedCert(hosts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile, keyPEM, 0600)
+}
+
+// 返回服务器 tls 配置，没有配置证书时返回 nil。开发模式下未配置证书时，自动生成自签名证书 ~/.gochat/server.crt 和 ~/.gochat/server.key
+func serverTLSConfig(c *config_t) (*tls.Config, error) {
+	certFile, keyFile := c.TLSCert, c.TLSKey
+	if c.TLSDev && len(certFile) == 0 {
+		certFile = filepath.Join(lib.WorkDir, "server.crt")
+		keyFile = filepath.Join(lib.WorkDir, "server.key")
+	}
+	if len(certFile) == 0 {
+		return nil, nil
+	}
+
+	if c.TLSDev {
+		if err := ensureDevCert(certFile, keyFile, c.TLSHosts); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 输出证书指纹，客户端可以通过 TLS_FINGERPRINT 固定服务器证书
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		slog.Info("tls certificate", "file", certFile, "sha256", lib.CertFingerprint(leaf.Raw))
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// 监听 addr，config 不为 nil 时使用 tls
+func listen(addr string, config *tls.Config) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil || config == nil {
+		return ln, err
+	}
+	return tls.NewListener(ln, config), nil
+}
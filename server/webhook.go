@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// 请求体签名请求头，值为 sha256=<hex(HMAC-SHA256(secret, body))>
+	WEBHOOK_SIGNATURE_HEADER = "X-GoChat-Signature"
+	// 消息 id 请求头，重试时不变，接收方可以用来去重
+	WEBHOOK_DELIVERY_HEADER = "X-GoChat-Delivery"
+	// 并发投递的协程数量
+	WEBHOOK_WORKERS = 4
+	// 等待投递的事件队列长度，队列已满时直接写入死信日志
+	WEBHOOK_QUEUE = 1024
+)
+
+// webhook 配置，消息匹配 users、groups 或 mentions 任意一项时向 url 发送事件
+type webhook_config_t struct {
+	// 接收事件的 URL
+	URL string `yaml:"url"`
+	// 签名密钥
+	Secret string `yaml:"secret"`
+	// 发送给这些用户的单聊消息
+	Users []string `yaml:"users"`
+	// 发送到这些群组的消息
+	Groups []uint64 `yaml:"groups"`
+	// 文本中提到 @name 的消息，加密消息不会匹配
+	Mentions []string `yaml:"mentions"`
+}
+
+// 判断消息是否需要发送给当前 webhook
+func (h *webhook_config_t) match(msg *lib.Msg) bool {
+	if msg.Group > 0 {
+		for _, group := range h.Groups {
+			if group == msg.Group {
+				return true
+			}
+		}
+	} else {
+		for _, user := range h.Users {
+			if user == msg.To {
+				return true
+			}
+		}
+	}
+
+	if msg.Kind != lib.MsgKind_TEXT {
+		return false
+	}
+	for _, name := range h.Mentions {
+		if mentioned(string(msg.Data), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断 text 中是否提到 @name，@oncall 不会匹配 @oncall2
+func mentioned(text, name string) bool {
+	mention := "@" + name
+	for i := strings.Index(text, mention); i >= 0; {
+		end := i + len(mention)
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if end == len(text) || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			return true
+		}
+		j := strings.Index(text[end:], mention)
+		if j < 0 {
+			break
+		}
+		i = end + j
+	}
+	return false
+}
+
+// 计算请求体签名
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// 一次投递任务
+type webhook_job_t struct {
+	hook *webhook_config_t
+	// lib.Msg 的 protojson 格式
+	body []byte
+	id   int64
+}
+
+// 死信日志中的一行
+type dead_letter_t struct {
+	Time     time.Time       `json:"time"`
+	URL      string          `json:"url"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Msg      json.RawMessage `json:"msg"`
+}
+
+// 不需要重试的错误，如 4xx 响应
+type permanent_error_t struct {
+	error
+}
+
+// 把消息事件异步发送到外部系统，失败时按照指数回退重试，重试次数用完后写入死信日志
+type webhooks_t struct {
+	hooks   []webhook_config_t
+	client  *http.Client
+	retries int
+	// 第 i 次重试前的等待时间
+	backoff func(i int) time.Duration
+
+	jobs chan *webhook_job_t
+	// 关闭后不再重试，未投递成功的事件写入死信日志
+	quit chan struct{}
+	// notify 持有读锁检查 quit 并加入队列，close 持有写锁关闭 quit，保证关闭后不会再有事件进入队列
+	closing sync.RWMutex
+	wg      sync.WaitGroup
+
+	mu sync.Mutex
+	// 关闭后为 nil，之后的死信每次打开 deadLetterPath 追加写入
+	deadLetter     *os.File
+	deadLetterPath string
+}
+
+// 根据配置创建 webhooks 并启动投递协程，没有配置 webhook 时返回 nil
+func newWebhooks(c *config_t) (*webhooks_t, error) {
+	if len(c.Webhooks) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(c.WebhookDeadLetter, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &webhooks_t{
+		hooks:          c.Webhooks,
+		client:         &http.Client{Timeout: c.WebhookTimeout},
+		retries:        c.WebhookRetries,
+		backoff:        webhookBackoff,
+		jobs:           make(chan *webhook_job_t, WEBHOOK_QUEUE),
+		quit:           make(chan struct{}),
+		deadLetter:     f,
+		deadLetterPath: c.WebhookDeadLetter,
+	}
+	for i := 0; i < WEBHOOK_WORKERS; i++ {
+		w.wg.Add(1)
+		go w.work()
+	}
+	return w, nil
+}
+
+// 指数回退，从 1s 开始，最长 1min，并增加随机抖动
+func webhookBackoff(i int) time.Duration {
+	d := time.Duration(math.Min(float64(time.Second)*math.Pow(2, float64(i)), float64(time.Minute)))
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// 把消息加入投递队列，不会阻塞。w 为 nil 时表示没有配置 webhook。关闭后直接写入死信日志
+func (w *webhooks_t) notify(msg *lib.Msg) {
+	if w == nil {
+		return
+	}
+
+	w.closing.RLock()
+	defer w.closing.RUnlock()
+
+	var body []byte
+	for i := range w.hooks {
+		hook := &w.hooks[i]
+		if !hook.match(msg) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			if body, err = (protojson.MarshalOptions{UseProtoNames: true}).Marshal(msg); err != nil {
+				slog.Error("marshal webhook event", "msg_id", msg.Id, "err", err)
+				return
+			}
+		}
+
+		job := &webhook_job_t{hook, body, msg.Id}
+		// 超过 ShutdownTimeout 后仍在处理的请求可能在关闭后调用 notify，此时投递协程已经退出
+		select {
+		case <-w.quit:
+			w.dead(job, 0, errors.New("server shutting down"))
+			continue
+		default:
+		}
+		select {
+		case w.jobs <- job:
+		default:
+			w.dead(job, 0, errors.New("queue full"))
+		}
+	}
+}
+
+// 投递协程
+func (w *webhooks_t) work() {
+	defer w.wg.Done()
+	for {
+		select {
+		case job := <-w.jobs:
+			w.deliver(job)
+		case <-w.quit:
+			// 队列中剩余的事件写入死信日志
+			for {
+				select {
+				case job := <-w.jobs:
+					w.dead(job, 0, errors.New("server shutting down"))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// 投递一个事件，失败时重试
+func (w *webhooks_t) deliver(job *webhook_job_t) {
+	var err error
+	attempts := 0
+	for attempts <= w.retries {
+		if attempts > 0 {
+			select {
+			case <-time.After(w.backoff(attempts - 1)):
+			case <-w.quit:
+				w.dead(job, attempts, fmt.Errorf("server shutting down: %w", err))
+				return
+			}
+		}
+
+		attempts++
+		if err = w.post(job); err == nil {
+			return
+		}
+
+		var permanent *permanent_error_t
+		if errors.As(err, &permanent) {
+			break
+		}
+	}
+	w.dead(job, attempts, err)
+}
+
+// 发送一次 http 请求，网络错误、5xx 和 429 响应可以重试
+func (w *webhooks_t) post(job *webhook_job_t) error {
+	req, err := http.NewRequest(http.MethodPost, job.hook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return &permanent_error_t{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, strconv.FormatInt(job.id, 10))
+	req.Header.Set(WEBHOOK_SIGNATURE_HEADER, webhookSignature(job.hook.Secret, job.body))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("http status %d", res.StatusCode)
+	default:
+		return &permanent_error_t{fmt.Errorf("http status %d", res.StatusCode)}
+	}
+}
+
+// 写入死信日志，每行一个 json 对象
+func (w *webhooks_t) dead(job *webhook_job_t, attempts int, err error) {
+	line, e := json.Marshal(&dead_letter_t{
+		Time:     time.Now(),
+		URL:      job.hook.URL,
+		Attempts: attempts,
+		Error:    err.Error(),
+		Msg:      job.body,
+	})
+	if e != nil {
+		slog.Error("marshal webhook dead letter", "url", job.hook.URL, "msg_id", job.id, "err", e)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f := w.deadLetter
+	if f == nil {
+		if f, e = os.OpenFile(w.deadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); e != nil {
+			slog.Error("open webhook dead letter", "url", job.hook.URL, "msg_id", job.id, "err", e)
+			return
+		}
+		defer f.Close()
+	}
+	if _, e := f.Write(append(line, '\n')); e != nil {
+		slog.Error("write webhook dead letter", "url", job.hook.URL, "msg_id", job.id, "err", e)
+	}
+}
+
+// 停止投递，正在等待重试和队列中的事件写入死信日志。w 为 nil 时直接返回
+func (w *webhooks_t) close() {
+	if w == nil {
+		return
+	}
+	w.closing.Lock()
+	close(w.quit)
+	w.closing.Unlock()
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.deadLetter.Close()
+	w.deadLetter = nil
+}
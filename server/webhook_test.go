@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/huoyijie/GoChat/lib"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// 创建指向 url 的 webhooks，重试不等待
+func newTestWebhooks(t *testing.T, url string, retries int) (*webhooks_t, string) {
+	c := defaultConfig()
+	c.Webhooks = []webhook_config_t{{URL: url, Secret: testWebhookSecret, Users: []string{"oncall"}, Groups: []uint64{7}, Mentions: []string{"oncall"}}}
+	c.WebhookRetries = retries
+	c.WebhookTimeout = time.Second
+	c.WebhookDeadLetter = filepath.Join(t.TempDir(), "dead-letter.log")
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newWebhooks(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.backoff = func(int) time.Duration { return 0 }
+	return w, c.WebhookDeadLetter
+}
+
+// 读取死信日志
+func readDeadLetters(t *testing.T, path string) (letters []dead_letter_t) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter dead_letter_t
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatal(err)
+		}
+		letters = append(letters, letter)
+	}
+	return
+}
+
+// 通过 biz_recv_msg_t 发送消息，webhook 前两次返回 500，第三次验证签名并解析消息
+func TestWebhookDeliver(t *testing.T) {
+	var attempts int32
+	received := make(chan *lib.Msg, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if got, want := r.Header.Get(WEBHOOK_SIGNATURE_HEADER), webhookSignature(testWebhookSecret, body); got != want {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+		msg := &lib.Msg{}
+		if err := protojson.Unmarshal(body, msg); err != nil {
+			t.Error(err)
+		}
+		if got := r.Header.Get(WEBHOOK_DELIVERY_HEADER); got != strconv.FormatInt(msg.Id, 10) {
+			t.Errorf("delivery = %s, want %d", got, msg.Id)
+		}
+		received <- msg
+	}))
+	defer srv.Close()
+
+	webhooks, deadLetter := newTestWebhooks(t, srv.URL, 3)
+
+	storage := newMemoryStore()
+	if err := storage.NewAccount(&Account{Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	node, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventChan := make(chan event_i, conf.EventBuffer)
+	poster := &api_poster_t{}
+	rm := initialRecvMsg(initialBase(1, poster, eventChan, nil, storage), node, webhooks)
+
+	data, err := lib.Marshal(&lib.Msg{To: "alice", Data: []byte("hello @oncall")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accId, accUN := uint64(1), "bob"
+	if err := rm.do(&lib.Packet{Kind: lib.PackKind_MSG, Data: data}, &accId, &accUN); err != nil {
+		t.Fatal(err)
+	}
+	msgRes, ok := poster.res.(*lib.MsgRes)
+	if !ok || msgRes.Code < 0 {
+		t.Fatalf("unexpected response %v", poster.res)
+	}
+
+	select {
+	case msg := <-received:
+		if msg.Id != msgRes.Id || msg.From != "bob" || msg.To != "alice" || string(msg.Data) != "hello @oncall" {
+			t.Errorf("unexpected msg %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+
+	webhooks.close()
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+	if letters := readDeadLetters(t, deadLetter); len(letters) != 0 {
+		t.Errorf("unexpected dead letters %v", letters)
+	}
+}
+
+// 重试用完或者返回 4xx 后写入死信日志
+func TestWebhookDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int32
+	}{
+		{"retry", http.StatusServiceUnavailable, 3},
+		{"permanent", http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			webhooks, deadLetter := newTestWebhooks(t, srv.URL, 2)
+			webhooks.notify(&lib.Msg{Id: 42, From: "bob", To: "oncall", Data: []byte("ping")})
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(readDeadLetters(t, deadLetter)) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("dead letter not written")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			webhooks.close()
+
+			letters := readDeadLetters(t, deadLetter)
+			if len(letters) != 1 {
+				t.Fatalf("dead letters = %d, want 1", len(letters))
+			}
+			if letters[0].URL != srv.URL || int32(letters[0].Attempts) != tt.attempts {
+				t.Errorf("unexpected dead letter %+v", letters[0])
+			}
+			if n := atomic.LoadInt32(&attempts); n != tt.attempts {
+				t.Errorf("attempts = %d, want %d", n, tt.attempts)
+			}
+
+			msg := &lib.Msg{}
+			if err := protojson.Unmarshal(letters[0].Msg, msg); err != nil || msg.Id != 42 {
+				t.Errorf("dead letter msg = %v, %v", msg, err)
+			}
+		})
+	}
+}
+
+// 关闭后调用 notify 的事件直接写入死信日志
+func TestWebhookNotifyAfterClose(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+	}))
+	defer srv.Close()
+
+	webhooks, deadLetter := newTestWebhooks(t, srv.URL, 2)
+	webhooks.close()
+	webhooks.notify(&lib.Msg{Id: 42, From: "bob", To: "oncall", Data: []byte("ping")})
+
+	letters := readDeadLetters(t, deadLetter)
+	if len(letters) != 1 || letters[0].Attempts != 0 || letters[0].Error != "server shutting down" {
+		t.Fatalf("dead letters = %+v", letters)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 0 {
+		t.Errorf("attempts = %d, want 0", n)
+	}
+	if len(webhooks.jobs) > 0 {
+		t.Error("event queued after close")
+	}
+}
+
+func TestWebhookMatch(t *testing.T) {
+	hook := &webhook_config_t{Users: []string{"oncall"}, Groups: []uint64{7}, Mentions: []string{"oncall"}}
+
+	tests := []struct {
+		msg  *lib.Msg
+		want bool
+	}{
+		{&lib.Msg{To: "oncall"}, true},
+		{&lib.Msg{To: "alice"}, false},
+		{&lib.Msg{To: "oncall", Group: 8}, false},
+		{&lib.Msg{Group: 7}, true},
+		{&lib.Msg{To: "alice", Data: []byte("@oncall help")}, true},
+		{&lib.Msg{To: "alice", Data: []byte("ping @oncall")}, true},
+		{&lib.Msg{To: "alice", Data: []byte("ping @oncall2, cc @oncall.")}, true},
+		{&lib.Msg{To: "alice", Data: []byte("ping @oncall2")}, false},
+		{&lib.Msg{To: "alice", Data: []byte("oncall")}, false},
+		{&lib.Msg{To: "alice", Kind: lib.MsgKind_SEALED, Data: []byte("@oncall")}, false},
+	}
+
+	for _, tt := range tests {
+		if got := hook.match(tt.msg); got != tt.want {
+			t.Errorf("match(%v) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/huoyijie/GoChat/lib"
+)
+
+// websocket 连接地址路径
+const WS_PATH = "/ws"
+
+// 把 websocket 连接包装为 net.Conn。每个 websocket 二进制消息是一个不带长度字段的 lib.Packet，读取时补上长度字段，写入时去掉长度字段，handleConn 可以像处理 tcp 连接一样处理 websocket 连接
+type ws_conn_t struct {
+	*websocket.Conn
+	max uint32
+	// 读缓冲区，包括长度字段
+	rbuf bytes.Buffer
+	// 当前消息未读取的部分
+	r []byte
+	// 未写完的帧
+	w []byte
+}
+
+func newWSConn(conn *websocket.Conn, max uint32) *ws_conn_t {
+	return &ws_conn_t{Conn: conn, max: max}
+}
+
+// 读取下一个二进制消息，并在开头加上长度字段。消息超过 max 时只读取 max+1 字节，之后 lib.FrameReader 会返回 FrameSizeError
+func (c *ws_conn_t) next() error {
+	for {
+		kind, r, err := c.NextReader()
+		if err != nil {
+			// 客户端正常关闭连接
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return io.EOF
+			}
+			return err
+		}
+		// 忽略文本消息
+		if kind != websocket.BinaryMessage {
+			continue
+		}
+
+		c.rbuf.Reset()
+		c.rbuf.Write(make([]byte, lib.PackLenField))
+		if _, err := c.rbuf.ReadFrom(io.LimitReader(r, int64(c.max)+1)); err != nil {
+			return err
+		}
+
+		c.r = c.rbuf.Bytes()
+		copy(c.r, lib.Uint32ToBytes(uint32(len(c.r)-lib.PackLenField)))
+		return nil
+	}
+}
+
+// Read implements net.Conn
+func (c *ws_conn_t) Read(p []byte) (n int, err error) {
+	if len(c.r) == 0 {
+		if err = c.next(); err != nil {
+			return
+		}
+	}
+	n = copy(p, c.r)
+	c.r = c.r[n:]
+	return
+}
+
+// Write implements net.Conn，每个完整的帧作为一个二进制消息发送
+func (c *ws_conn_t) Write(p []byte) (int, error) {
+	buf := append(c.w, p...)
+	off := 0
+	for len(buf)-off >= lib.PackLenField {
+		end := off + lib.PackLenField + int(lib.BytesToUint32(buf[off:off+lib.PackLenField]))
+		if len(buf) < end {
+			break
+		}
+		if err := c.WriteMessage(websocket.BinaryMessage, buf[off+lib.PackLenField:end]); err != nil {
+			return 0, err
+		}
+		off = end
+	}
+	// 未写完的帧移到缓冲区开头，复用写缓冲区
+	c.w = buf[:copy(buf, buf[off:])]
+	return len(p), nil
+}
+
+// SetDeadline implements net.Conn
+func (c *ws_conn_t) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+// Close implements net.Conn，关闭前尽量通知客户端
+func (c *ws_conn_t) Close() error {
+	c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return c.Conn.Close()
+}
+
+var _ net.Conn = (*ws_conn_t)(nil)
+
+// websocket 监听器，接受 websocket 连接升级请求，升级后的连接通过 Accept 返回
+type ws_listener_t struct {
+	ln       net.Listener
+	srv      *http.Server
+	upgrader websocket.Upgrader
+	conns    chan net.Conn
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// 监听 c.WSAddr，config 不为 nil 时使用 tls
+func listenWS(c *config_t, config *tls.Config) (*ws_listener_t, error) {
+	ln, err := listen(c.WSAddr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ws_listener_t{
+		ln:     ln,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+	l.upgrader.CheckOrigin = checkOrigin(c.WSOrigins)
+
+	mux := http.NewServeMux()
+	mux.Handle(WS_PATH, l)
+	l.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := l.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("websocket listener stopped", "addr", c.WSAddr, "err", err)
+		}
+	}()
+	return l, nil
+}
+
+// 没有配置 origins 时只允许同源请求，配置为 * 时允许所有来源
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if len(origin) == 0 {
+			return true
+		}
+		if len(origins) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && u.Host == r.Host
+		}
+		for _, o := range origins {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (l *ws_listener_t) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := l.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已向客户端返回错误
+		return
+	}
+
+	select {
+	case l.conns <- newWSConn(conn, uint32(conf.MaxFrameSize)):
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+// Accept implements net.Listener
+func (l *ws_listener_t) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener，已经升级的连接不受影响
+func (l *ws_listener_t) Close() (err error) {
+	l.once.Do(func() {
+		close(l.closed)
+		err = l.srv.Close()
+	})
+	return
+}
+
+// Addr implements net.Listener
+func (l *ws_listener_t) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+var _ net.Listener = (*ws_listener_t)(nil)
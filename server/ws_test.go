@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/huoyijie/GoChat/lib"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/proto"
+)
+
+// 启动 websocket 服务器，升级后的连接与 tcp 连接一样由 handleConn 处理
+func newWSServer(t *testing.T) string {
+	t.Helper()
+	// 降低 bcrypt 计算强度，所有连接退出后恢复配置
+	saved := conf
+	t.Cleanup(func() { conf = saved })
+	c := *conf
+	c.BcryptCost = bcrypt.MinCost
+	conf = &c
+
+	storage := newMemoryStore()
+	eventChan := make(chan event_i, conf.EventBuffer)
+	pushChan := make(chan *lib.Push, conf.EventBuffer)
+	go handlePush(eventChan, pushChan, storage)
+
+	l := &ws_listener_t{conns: make(chan net.Conn), closed: make(chan struct{})}
+	srv := httptest.NewServer(l)
+	t.Cleanup(func() {
+		close(l.closed)
+		srv.Close()
+	})
+
+	quit := make(chan struct{})
+	var conns sync.WaitGroup
+	t.Cleanup(func() {
+		close(quit)
+		conns.Wait()
+	})
+	go func() {
+		for sid := uint64(1); ; sid++ {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			go func(sid uint64) {
+				defer conns.Done()
+				handleConn(conn, sid, quit, eventChan, pushChan, storage, testNode, nil)
+			}(sid)
+		}
+	}()
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + WS_PATH
+}
+
+// 每个二进制消息是一个不带长度字段的 packet
+func wsRoundTrip(t *testing.T, conn *websocket.Conn, id uint64, kind lib.PackKind, req, res proto.Message) {
+	t.Helper()
+	data, err := lib.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := lib.Marshal(&lib.Packet{Id: id, Kind: kind, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, bytes); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, bytes, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pack := &lib.Packet{}
+		if err := lib.Unmarshal(bytes, pack); err != nil {
+			t.Fatal(err)
+		}
+		// 忽略 push 等其他 packet
+		if pack.Kind == lib.PackKind_RES && pack.Id == id {
+			if err := lib.Unmarshal(pack.Data, res); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+// websocket 客户端握手后注册、登录，超过最大长度的消息会断开连接
+func TestWebSocket(t *testing.T) {
+	testKeys(t)
+	url := newWSServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	helloRes := &lib.HelloRes{}
+	wsRoundTrip(t, conn, 1, lib.PackKind_HELLO, &lib.Hello{Version: lib.PROTO_VERSION}, helloRes)
+	if helloRes.Code < 0 || helloRes.MaxFrameSize != uint32(conf.MaxFrameSize) {
+		t.Fatalf("hello: %v", helloRes)
+	}
+
+	passhash := sha256.Sum256([]byte("hello123"))
+	auth := &lib.Auth{Username: "alice", Passhash: passhash[:]}
+	tokenRes := &lib.TokenRes{}
+	wsRoundTrip(t, conn, 2, lib.PackKind_SIGNUP, &lib.Signup{Auth: auth}, tokenRes)
+	if tokenRes.Code < 0 {
+		t.Fatalf("signup: %v", tokenRes)
+	}
+	tokenRes = &lib.TokenRes{}
+	wsRoundTrip(t, conn, 3, lib.PackKind_SIGNIN, &lib.Signin{Auth: auth}, tokenRes)
+	if tokenRes.Code < 0 || tokenRes.Username != "alice" || len(tokenRes.Token) == 0 {
+		t.Fatalf("signin: %v", tokenRes)
+	}
+
+	// 超过最大长度的消息
+	if err := conn.WriteMessage(websocket.BinaryMessage, make([]byte, conf.MaxFrameSize+1)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				t.Fatal("oversized message should close the connection")
+			}
+			return
+		}
+	}
+}